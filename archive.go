@@ -0,0 +1,143 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	tweetsCsvFileName = "tweets.csv"
+	archiveTimeLayout = "2006-01-02 15:04:05 -0700"
+)
+
+type zipFileReadCloser struct {
+	io.ReadCloser
+	zr *zip.ReadCloser
+}
+
+func (c zipFileReadCloser) Close() error {
+	if err := c.ReadCloser.Close(); err != nil {
+		c.zr.Close()
+		return err
+	}
+
+	return c.zr.Close()
+}
+
+// openZipFile opens the named file in the zip archive.
+// Closing the returned reader also closes the zip archive.
+func openZipFile(zipPath, name string) (io.ReadCloser, error) {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			zr.Close()
+			return nil, err
+		}
+
+		return zipFileReadCloser{ReadCloser: rc, zr: zr}, nil
+	}
+
+	zr.Close()
+	return nil, errors.Errorf("%s is not found in %s.", name, zipPath)
+}
+
+// readArchiveCsv reads all rows of tweets.csv as archived tweets of the user.
+func readArchiveCsv(r io.Reader, userID uint64) ([]*model.ArchivedTweet, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := map[string]int{}
+	for i, name := range header {
+		indexes[name] = i
+	}
+
+	for _, name := range []string{"tweet_id", "timestamp", "text"} {
+		if _, ok := indexes[name]; !ok {
+			return nil, errors.Errorf("%s column is not found.", name)
+		}
+	}
+
+	column := func(record []string, name string) string {
+		i, ok := indexes[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+
+		return record[i]
+	}
+
+	var ats []*model.ArchivedTweet
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return ats, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		at := &model.ArchivedTweet{TwitterUserID: userID, Source: column(record, "source"),
+			Tweet: column(record, "text"), ExpandedURLs: column(record, "expanded_urls")}
+
+		for name, id := range map[string]*uint64{
+			"tweet_id":                 &at.TwitterTweetID,
+			"in_reply_to_status_id":    &at.InReplyToStatusID,
+			"in_reply_to_user_id":      &at.InReplyToUserID,
+			"retweeted_status_id":      &at.RetweetedStatusID,
+			"retweeted_status_user_id": &at.RetweetedStatusUserID,
+		} {
+			v := column(record, name)
+			if v == "" {
+				continue
+			}
+
+			*id, err = strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		postedAt, err := time.Parse(archiveTimeLayout, column(record, "timestamp"))
+		if err != nil {
+			return nil, err
+		}
+
+		at.PostedAt = postedAt.UTC()
+		ats = append(ats, at)
+	}
+}
+
+// readArchiveJS decodes the archive js file (e.g. window.YTD.like.part0 = [...]) into v.
+func readArchiveJS(r io.Reader, v interface{}) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	i := bytes.IndexByte(b, '=')
+	if i < 0 {
+		return errors.New("Archive js assignment is not found.")
+	}
+
+	return json.Unmarshal(b[i+1:], v)
+}
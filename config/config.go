@@ -2,16 +2,74 @@ package config
 
 import (
 	"io/ioutil"
+	"strings"
 
 	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
 )
 
-// Config is ...
+// Database drivers.
+const (
+	DriverMySQL    = "mysql"
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// Config is the settings of the config file.
 type Config struct {
 	ConsumerKey       string `toml:"consumer_key"`
 	ConsumerSecret    string `toml:"consumer_secret"`
 	AccessToken       string `toml:"access_token"`
 	AccessTokenSecret string `toml:"access_token_secret"`
+
+	// ContentHashSalt is the salt of the tweet hash kept by --store-content hash.
+	ContentHashSalt string `toml:"content_hash_salt"`
+
+	// SearchEnv is the dev environment label of the premium full-archive search used by --search.
+	SearchEnv string `toml:"search_env"`
+
+	Database Database `toml:"database"`
+}
+
+// Database is database connection settings of [database] section.
+// Driver is mysql, sqlite or postgres. Path is the database file of sqlite.
+// DSN takes precedence over Host, User, Password, PasswordFile, Name and Path.
+type Database struct {
+	Driver       string `toml:"driver"`
+	DSN          string `toml:"dsn"`
+	Host         string `toml:"host"`
+	User         string `toml:"user"`
+	Password     string `toml:"password"`
+	PasswordFile string `toml:"password_file"`
+	Name         string `toml:"name"`
+	Path         string `toml:"path"`
+
+	// TLS is true, false, skip-verify or preferred.
+	// When TLSCAFile or TLSCertFile is set, the certificates are used.
+	TLS         string `toml:"tls"`
+	TLSCAFile   string `toml:"tls_ca_file"`
+	TLSCertFile string `toml:"tls_cert_file"`
+	TLSKeyFile  string `toml:"tls_key_file"`
+
+	// MaxOpenConns takes precedence over MaxOpenConnsPercent of the server max connections.
+	MaxOpenConns        int    `toml:"max_open_conns"`
+	MaxOpenConnsPercent int    `toml:"max_open_conns_percent"`
+	MaxIdleConns        int    `toml:"max_idle_conns"`
+	ConnMaxLifetime     string `toml:"conn_max_lifetime"`
+}
+
+// ReadPassword returns Password or the content of PasswordFile without the trailing newline.
+func (d Database) ReadPassword() (string, error) {
+	if d.PasswordFile == "" {
+		return d.Password, nil
+	}
+
+	b, err := ioutil.ReadFile(d.PasswordFile)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(b), "\r\n"), nil
 }
 
 // LoadConfig is ...
@@ -21,11 +79,19 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, err
 	}
 
-	var config *Config
-	_, err = toml.Decode(string(configFile), &config)
+	// Default values for the omitted keys.
+	config := &Config{Database: Database{Driver: DriverMySQL,
+		User: "root", Name: "tweeraser", Path: "tweeraser.db", MaxOpenConnsPercent: 50}}
+	_, err = toml.Decode(string(configFile), config)
 	if err != nil {
 		return nil, err
 	}
 
+	switch config.Database.Driver {
+	case DriverMySQL, DriverSQLite, DriverPostgres:
+	default:
+		return nil, errors.Errorf("Unknown database driver: %s.", config.Database.Driver)
+	}
+
 	return config, nil
 }
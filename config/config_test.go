@@ -18,6 +18,8 @@ func TestLoadConfig(t *testing.T) {
 consumer_secret = "bar"
 access_token = "baz"
 access_token_secret = "foobar"
+content_hash_salt = "salt"
+search_env = "dev"
 `
 	_, err = file.WriteString(fileStr)
 	assert.NoError(t, err)
@@ -30,8 +32,91 @@ access_token_secret = "foobar"
 	assert.Equal(t, "bar", conf.ConsumerSecret)
 	assert.Equal(t, "baz", conf.AccessToken)
 	assert.Equal(t, "foobar", conf.AccessTokenSecret)
+	assert.Equal(t, "salt", conf.ContentHashSalt)
+	assert.Equal(t, "dev", conf.SearchEnv)
+
+	// Database defaults.
+	assert.Equal(t, "mysql", conf.Database.Driver)
+	assert.Equal(t, "root", conf.Database.User)
+	assert.Equal(t, "tweeraser", conf.Database.Name)
+	assert.Equal(t, "tweeraser.db", conf.Database.Path)
+	assert.Equal(t, 50, conf.Database.MaxOpenConnsPercent)
 
 	conf, err = config.LoadConfig("path/nothing.toml")
 	assert.Nil(t, conf)
 	assert.Error(t, err)
 }
+
+func TestLoadConfigDatabase(t *testing.T) {
+	file, err := ioutil.TempFile("", "")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	fileStr := `consumer_key = "foo"
+
+[database]
+host = "db.example.com:3306"
+user = "tweeraser"
+password = "secret"
+name = "tweeraser_prod"
+tls = "true"
+max_open_conns = 10
+max_open_conns_percent = 80
+max_idle_conns = 2
+conn_max_lifetime = "5m"
+`
+	_, err = file.WriteString(fileStr)
+	assert.NoError(t, err)
+	file.Close()
+
+	conf, err := config.LoadConfig(file.Name())
+	assert.NoError(t, err)
+
+	assert.Equal(t, "db.example.com:3306", conf.Database.Host)
+	assert.Equal(t, "tweeraser", conf.Database.User)
+	assert.Equal(t, "tweeraser_prod", conf.Database.Name)
+	assert.Equal(t, "true", conf.Database.TLS)
+	assert.Equal(t, 10, conf.Database.MaxOpenConns)
+	assert.Equal(t, 80, conf.Database.MaxOpenConnsPercent)
+	assert.Equal(t, 2, conf.Database.MaxIdleConns)
+	assert.Equal(t, "5m", conf.Database.ConnMaxLifetime)
+
+	password, err := conf.Database.ReadPassword()
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", password)
+}
+
+func TestLoadConfigUnknownDriver(t *testing.T) {
+	file, err := ioutil.TempFile("", "")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString("[database]\ndriver = \"oracle\"\n")
+	assert.NoError(t, err)
+	file.Close()
+
+	conf, err := config.LoadConfig(file.Name())
+	assert.Nil(t, conf)
+	assert.Error(t, err)
+}
+
+func TestDatabaseReadPassword(t *testing.T) {
+	file, err := ioutil.TempFile("", "")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString("file_secret\n")
+	assert.NoError(t, err)
+	file.Close()
+
+	// Password file takes precedence.
+	d := config.Database{Password: "secret", PasswordFile: file.Name()}
+	password, err := d.ReadPassword()
+	assert.NoError(t, err)
+	assert.Equal(t, "file_secret", password)
+
+	d = config.Database{PasswordFile: "path/nothing"}
+	password, err = d.ReadPassword()
+	assert.Error(t, err)
+	assert.Equal(t, "", password)
+}
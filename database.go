@@ -0,0 +1,134 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/178inaba/tweeraser/config"
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/journal"
+	"github.com/178inaba/tweeraser/model/mysql"
+	"github.com/178inaba/tweeraser/model/postgres"
+	"github.com/178inaba/tweeraser/model/sqlite"
+	"github.com/pkg/errors"
+)
+
+func newDB(conf config.Database) (*sql.DB, error) {
+	open := mysql.Open
+	switch conf.Driver {
+	case config.DriverSQLite:
+		open = sqlite.Open
+	case config.DriverPostgres:
+		open = postgres.Open
+	}
+
+	db, err := open(conf)
+	if err != nil {
+		return nil, errors.Errorf("Fail db open: %s.", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, errors.Errorf("Fail db ping: %s.", err)
+	}
+
+	if err := setDBPool(db, conf); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// setDBPool sets the connection pool settings of [database] config.
+func setDBPool(db *sql.DB, conf config.Database) error {
+	if conf.Driver == config.DriverSQLite {
+		// SQLite allows only one writer, so serialize the connections.
+		db.SetMaxOpenConns(1)
+		return nil
+	}
+
+	setMaxOpenConnsFromDB := mysql.SetMaxOpenConnsFromDB
+	if conf.Driver == config.DriverPostgres {
+		setMaxOpenConnsFromDB = postgres.SetMaxOpenConnsFromDB
+	}
+
+	if conf.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(conf.MaxOpenConns)
+	} else if err := setMaxOpenConnsFromDB(db, conf.MaxOpenConnsPercent); err != nil {
+		return err
+	}
+
+	if conf.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(conf.MaxIdleConns)
+	}
+
+	if conf.ConnMaxLifetime != "" {
+		d, err := time.ParseDuration(conf.ConnMaxLifetime)
+		if err != nil {
+			return errors.Errorf("Fail parse conn_max_lifetime: %s.", err)
+		}
+
+		db.SetConnMaxLifetime(d)
+	}
+
+	return nil
+}
+
+// newSchemaMigrationService returns the schema migration service of the driver.
+func newSchemaMigrationService(driver string, db *sql.DB) model.SchemaMigrationService {
+	switch driver {
+	case config.DriverSQLite:
+		return sqlite.NewSchemaMigrationService(db)
+	case config.DriverPostgres:
+		return postgres.NewSchemaMigrationService(db)
+	}
+
+	return mysql.NewSchemaMigrationService(db)
+}
+
+// newTwitterUserService returns the twitter user service of the driver.
+func newTwitterUserService(driver string, db *sql.DB) model.TwitterUserService {
+	switch driver {
+	case config.DriverSQLite:
+		return sqlite.NewTwitterUserService(db)
+	case config.DriverPostgres:
+		return postgres.NewTwitterUserService(db)
+	}
+
+	return mysql.NewTwitterUserService(db)
+}
+
+// journalServices is the services of the journal fallback.
+type journalServices struct {
+	eraseTweetService  model.EraseTweetService
+	eraseErrorService  model.EraseErrorService
+	eraseRunService    model.EraseRunService
+	twitterUserService model.TwitterUserService
+}
+
+// newJournalServices returns the journal services of erase tweets, erase errors, erase runs and twitter users.
+func newJournalServices(dir string) (*journalServices, error) {
+	ets, err := journal.NewEraseTweetService(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ees, err := journal.NewEraseErrorService(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ers, err := journal.NewEraseRunService(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	tus, err := journal.NewTwitterUserService(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &journalServices{eraseTweetService: ets, eraseErrorService: ees,
+		eraseRunService: ers, twitterUserService: tus}, nil
+}
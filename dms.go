@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/ChimeraCoder/anaconda"
+	log "github.com/Sirupsen/logrus"
+)
+
+const directMessagesJSFileName = "data/direct-messages.js"
+
+type directMessage struct {
+	id             uint64
+	conversationID string
+	senderID       uint64
+	recipientID    uint64
+	text           string
+	sentAt         time.Time
+}
+
+type archiveDMConversation struct {
+	DMConversation struct {
+		ConversationID string `json:"conversationId"`
+		Messages       []struct {
+			MessageCreate struct {
+				ID          string    `json:"id"`
+				SenderID    string    `json:"senderId"`
+				RecipientID string    `json:"recipientId"`
+				Text        string    `json:"text"`
+				CreatedAt   time.Time `json:"createdAt"`
+			} `json:"messageCreate"`
+		} `json:"messages"`
+	} `json:"dmConversation"`
+}
+
+type directMessageEvents struct {
+	Events []struct {
+		ID               string `json:"id"`
+		Type             string `json:"type"`
+		CreatedTimestamp string `json:"created_timestamp"`
+		MessageCreate    struct {
+			Target struct {
+				RecipientID string `json:"recipient_id"`
+			} `json:"target"`
+			SenderID    string `json:"sender_id"`
+			MessageData struct {
+				Text string `json:"text"`
+			} `json:"message_data"`
+		} `json:"message_create"`
+	} `json:"events"`
+	NextCursor string `json:"next_cursor"`
+}
+
+func (c tweetEraseClient) eraseDirectMessages() error {
+	dms, err := c.eventDirectMessages()
+	if err != nil {
+		return err
+	}
+
+	if *zipFilePath != "" {
+		archiveDMs, err := c.archiveDirectMessages()
+		if err != nil {
+			return err
+		}
+
+		dms = append(dms, archiveDMs...)
+	}
+
+	conversationIDs := map[string]struct{}{}
+	for _, id := range *dmConversationIDs {
+		conversationIDs[id] = struct{}{}
+	}
+
+	dmsMap := map[uint64]directMessage{}
+	ids := make([]uint64, 0, len(dms))
+	for _, dm := range dms {
+		if _, ok := conversationIDs[dm.conversationID]; len(conversationIDs) > 0 && !ok {
+			continue
+		}
+
+		dmsMap[dm.id] = dm
+		ids = append(ids, dm.id)
+	}
+
+	// Direct messages are recorded only in mysql.
+	var finders []idsFinder
+	if c.eraseDirectMessageService != nil {
+		finders = append(finders, c.eraseDirectMessageService.AlreadyEraseDirectMessageIDs)
+	}
+
+	validIDs, err := c.excludeIDs(ids, finders...)
+	if err != nil {
+		return err
+	}
+
+	return c.eraseIDs(validIDs, func(id uint64, wg *sync.WaitGroup) {
+		c.eraseDirectMessage(dmsMap[id], wg)
+	})
+}
+
+// eventDirectMessages returns direct messages of the events api.
+// The events api returns only the last 30 days messages.
+func (c tweetEraseClient) eventDirectMessages() ([]directMessage, error) {
+	api := newRawAPI(c.config)
+
+	v := url.Values{}
+	v.Set("count", fmt.Sprint(50))
+
+	var dms []directMessage
+	for {
+		var es directMessageEvents
+		if err := api.get("direct_messages/events/list.json", v, &es); err != nil {
+			return nil, err
+		}
+
+		for _, e := range es.Events {
+			if e.Type != "message_create" {
+				continue
+			}
+
+			dm, err := newDirectMessage(e.ID, e.MessageCreate.SenderID,
+				e.MessageCreate.Target.RecipientID, e.MessageCreate.MessageData.Text)
+			if err != nil {
+				return nil, err
+			}
+
+			msec, err := strconv.ParseInt(e.CreatedTimestamp, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+
+			dm.sentAt = time.Unix(0, msec*int64(time.Millisecond)).UTC()
+			dms = append(dms, dm)
+		}
+
+		if es.NextCursor == "" {
+			return dms, nil
+		}
+
+		v.Set("cursor", es.NextCursor)
+	}
+}
+
+func (c tweetEraseClient) archiveDirectMessages() ([]directMessage, error) {
+	rc, err := openZipFile(*zipFilePath, directMessagesJSFileName)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var conversations []archiveDMConversation
+	if err := readArchiveJS(rc, &conversations); err != nil {
+		return nil, err
+	}
+
+	var dms []directMessage
+	for _, conv := range conversations {
+		for _, m := range conv.DMConversation.Messages {
+			mc := m.MessageCreate
+			dm, err := newDirectMessage(mc.ID, mc.SenderID, mc.RecipientID, mc.Text)
+			if err != nil {
+				return nil, err
+			}
+
+			dm.conversationID = conv.DMConversation.ConversationID
+			dm.sentAt = mc.CreatedAt.UTC()
+			dms = append(dms, dm)
+		}
+	}
+
+	return dms, nil
+}
+
+// newDirectMessage creates direct message from string ids.
+// Conversation id is "<smaller user id>-<larger user id>" same as the archive.
+func newDirectMessage(id, senderID, recipientID, text string) (directMessage, error) {
+	dm := directMessage{text: text}
+	var err error
+	if dm.id, err = strconv.ParseUint(id, 10, 64); err != nil {
+		return directMessage{}, err
+	} else if dm.senderID, err = strconv.ParseUint(senderID, 10, 64); err != nil {
+		return directMessage{}, err
+	} else if dm.recipientID, err = strconv.ParseUint(recipientID, 10, 64); err != nil {
+		return directMessage{}, err
+	}
+
+	if dm.senderID < dm.recipientID {
+		dm.conversationID = fmt.Sprintf("%d-%d", dm.senderID, dm.recipientID)
+	} else {
+		dm.conversationID = fmt.Sprintf("%d-%d", dm.recipientID, dm.senderID)
+	}
+
+	return dm, nil
+}
+
+func (c tweetEraseClient) eraseDirectMessage(dm directMessage, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	l := log.WithFields(log.Fields{"id": dm.id, "conversation_id": dm.conversationID})
+
+	v := url.Values{}
+	v.Set("id", fmt.Sprint(dm.id))
+	err := newRawAPI(c.config).delete("direct_messages/events/destroy.json", v, nil)
+	if isDirectMessageNotFound(err) {
+		// The direct message already erased is recorded as erased, so it is not tried again.
+		l.Warnf("Direct message is already erased: %s", err)
+	} else if err != nil {
+		c.countFailure()
+		l.Errorf("Fail erase direct message: %s", err)
+		return
+	}
+
+	insertID, err := c.insertEraseDirectMessage(dm)
+	if err != nil {
+		l.Errorf("Fail erase direct message insert: %s", err)
+		return
+	} else if insertID != 0 {
+		l = l.WithField("insert_id", insertID)
+	}
+
+	// The text of the direct messages is private, so it is never logged.
+	l = l.WithFields(log.Fields{"sender_id": dm.senderID, "recipient_id": dm.recipientID,
+		"sent_at": dm.sentAt.Format("2006-01-02 15:04:05")})
+
+	l.Info("Successfully erased direct message!")
+}
+
+// isDirectMessageNotFound reports whether the error is the not found status or the does not exist code.
+func isDirectMessageNotFound(err error) bool {
+	apiErr, ok := err.(*anaconda.ApiError)
+	if !ok {
+		return false
+	} else if apiErr.StatusCode == http.StatusNotFound {
+		return true
+	}
+
+	for _, e := range apiErr.Decoded.Errors {
+		if e.Code == anaconda.TwitterErrorDoesNotExist {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c tweetEraseClient) insertEraseDirectMessage(dm directMessage) (uint64, error) {
+	if c.eraseDirectMessageService == nil {
+		return 0, nil
+	}
+
+	edm := &model.EraseDirectMessage{TwitterDirectMessageID: dm.id, ConversationID: dm.conversationID,
+		SenderID: dm.senderID, RecipientID: dm.recipientID, SentAt: dm.sentAt, TwitterUserID: c.user.UserID}
+	if *dmStoreMessage && *storeContent == model.StoreContentFull {
+		edm.Message = dm.text
+	}
+
+	insertID, err := c.eraseDirectMessageService.Insert(edm)
+	if err != nil {
+		return 0, err
+	}
+
+	return insertID, nil
+}
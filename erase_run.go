@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	log "github.com/Sirupsen/logrus"
+)
+
+// eraseRun is the recorded erase run counting the results of the erase goroutines.
+type eraseRun struct {
+	mu sync.Mutex
+	model.EraseRun
+}
+
+func (r *eraseRun) countErased() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ErasedCount++
+}
+
+func (r *eraseRun) countError() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ErrorCount++
+}
+
+// eraseRunOptions returns the flags changing how the tweets are erased.
+func eraseRunOptions() map[string]interface{} {
+	return map[string]interface{}{"csv_file": *csvFilePath, "zip_file": *zipFilePath,
+		"with_timeline": *withTimeline, "search": *eraseSearch, "backup_media": *backupMediaDir,
+		"store_content": *storeContent}
+}
+
+// startEraseRun records the start of the erase run of the sources and filters.
+func (c tweetEraseClient) startEraseRun(sources []string, filters map[string]interface{}) (*eraseRun, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	filtersJSON, err := json.Marshal(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	optionsJSON, err := json.Marshal(eraseRunOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	r := &eraseRun{EraseRun: model.EraseRun{TwitterUserID: c.user.UserID, Command: c.command,
+		Sources: strings.Join(sources, ","), Filters: string(filtersJSON), Options: string(optionsJSON),
+		Host: host, ToolVersion: version, StartedAt: time.Now().UTC()}}
+	r.ID, err = c.eraseRunService.Insert(&r.EraseRun)
+	if err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{"run_id": r.ID, "sources": r.Sources}).Info("Start erase run.")
+	return r, nil
+}
+
+// finishEraseRun records the result counts and the ended time of the erase run.
+func (c tweetEraseClient) finishEraseRun(r *eraseRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	endedAt := time.Now().UTC()
+	r.EndedAt = &endedAt
+	if err := c.eraseRunService.Update(&r.EraseRun); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"run_id": r.ID, "target": r.TargetCount,
+		"erased": r.ErasedCount, "error": r.ErrorCount}).Info("Finished erase run.")
+	return nil
+}
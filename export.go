@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/ChimeraCoder/anaconda"
+	log "github.com/Sirupsen/logrus"
+)
+
+// Export output formats.
+const (
+	exportFormatCSV     = "csv"
+	exportFormatJSON    = "json"
+	exportFormatTweetJS = "tweets.js"
+)
+
+// archiveCsvColumns is the header of tweets.csv of the twitter archive.
+var archiveCsvColumns = []string{"tweet_id", "in_reply_to_status_id", "in_reply_to_user_id", "timestamp", "source",
+	"text", "retweeted_status_id", "retweeted_status_user_id", "retweeted_status_timestamp", "expanded_urls"}
+
+// archiveTweet is the row of tweets.csv of the twitter archive.
+type archiveTweet struct {
+	TweetID                  string `json:"tweet_id"`
+	InReplyToStatusID        string `json:"in_reply_to_status_id"`
+	InReplyToUserID          string `json:"in_reply_to_user_id"`
+	Timestamp                string `json:"timestamp"`
+	Source                   string `json:"source"`
+	Text                     string `json:"text"`
+	RetweetedStatusID        string `json:"retweeted_status_id"`
+	RetweetedStatusUserID    string `json:"retweeted_status_user_id"`
+	RetweetedStatusTimestamp string `json:"retweeted_status_timestamp"`
+	ExpandedURLs             string `json:"expanded_urls"`
+}
+
+func (t archiveTweet) record() []string {
+	return []string{t.TweetID, t.InReplyToStatusID, t.InReplyToUserID, t.Timestamp, t.Source,
+		t.Text, t.RetweetedStatusID, t.RetweetedStatusUserID, t.RetweetedStatusTimestamp, t.ExpandedURLs}
+}
+
+// tweetJS is the tweet of tweets.js (window.YTD.tweet.part0 = [{"tweet": {...}}]) of the twitter archive.
+// The ids and counts are strings as in the archive.
+type tweetJS struct {
+	ID                   string             `json:"id"`
+	IDStr                string             `json:"id_str"`
+	FullText             string             `json:"full_text"`
+	CreatedAt            string             `json:"created_at"`
+	Source               string             `json:"source"`
+	InReplyToStatusID    string             `json:"in_reply_to_status_id,omitempty"`
+	InReplyToStatusIDStr string             `json:"in_reply_to_status_id_str,omitempty"`
+	InReplyToUserID      string             `json:"in_reply_to_user_id,omitempty"`
+	InReplyToUserIDStr   string             `json:"in_reply_to_user_id_str,omitempty"`
+	InReplyToScreenName  string             `json:"in_reply_to_screen_name,omitempty"`
+	Lang                 string             `json:"lang,omitempty"`
+	FavoriteCount        string             `json:"favorite_count"`
+	RetweetCount         string             `json:"retweet_count"`
+	Entities             anaconda.Entities  `json:"entities"`
+	ExtendedEntities     *anaconda.Entities `json:"extended_entities,omitempty"`
+}
+
+// export writes the erased tweets in the shape of the twitter archive to --out or stdout.
+func (c tweetEraseClient) export() error {
+	ets, err := c.eraseTweetService.EraseTweets(c.user.UserID)
+	if err != nil {
+		return err
+	}
+
+	if *exportOut == "" {
+		err = writeExport(os.Stdout, ets)
+	} else {
+		err = writeExportFile(*exportOut, ets)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"format": *exportFormat, "count": len(ets)}).Info("Successfully exported!")
+	return nil
+}
+
+// writeExportFile writes the erased tweets to the file.
+// The file is closed explicitly, because the write error may be returned by close.
+func writeExportFile(path string, ets []*model.EraseTweet) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := writeExport(f, ets); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// writeExport writes the erased tweets in --format.
+func writeExport(w io.Writer, ets []*model.EraseTweet) error {
+	switch *exportFormat {
+	case exportFormatJSON:
+		ats := make([]archiveTweet, len(ets))
+		for i, et := range ets {
+			ats[i] = newArchiveTweet(et)
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(ats)
+	case exportFormatTweetJS:
+		return writeTweetJS(w, ets)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(archiveCsvColumns)
+	for _, et := range ets {
+		cw.Write(newArchiveTweet(et).record())
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// rawTweet decodes the raw json of the erase tweet. The tweet erased before keeping raw json has no fields.
+func rawTweet(et *model.EraseTweet) anaconda.Tweet {
+	var t anaconda.Tweet
+	if et.RawJSON != "" {
+		if err := json.Unmarshal([]byte(et.RawJSON), &t); err != nil {
+			log.WithField("tweet_id", et.TwitterTweetID).Warnf("Fail decode raw json: %s", err)
+		}
+	}
+
+	return t
+}
+
+func newArchiveTweet(et *model.EraseTweet) archiveTweet {
+	t := rawTweet(et)
+	at := archiveTweet{TweetID: strconv.FormatUint(et.TwitterTweetID, 10),
+		Timestamp: et.PostedAt.UTC().Format(archiveTimeLayout), Source: t.Source, Text: et.Tweet,
+		InReplyToStatusID: formatArchiveID(t.InReplyToStatusID), InReplyToUserID: formatArchiveID(t.InReplyToUserID)}
+
+	if rt := t.RetweetedStatus; rt != nil {
+		at.RetweetedStatusID, at.RetweetedStatusUserID = formatArchiveID(rt.Id), formatArchiveID(rt.User.Id)
+		if postedAt, err := time.Parse(time.RubyDate, rt.CreatedAt); err == nil {
+			at.RetweetedStatusTimestamp = postedAt.UTC().Format(archiveTimeLayout)
+		}
+	}
+
+	var urls []string
+	for _, u := range t.Entities.Urls {
+		urls = append(urls, u.Expanded_url)
+	}
+
+	at.ExpandedURLs = strings.Join(urls, ",")
+	return at
+}
+
+// formatArchiveID formats the id as tweets.csv, 0 is the empty column.
+func formatArchiveID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+
+	return strconv.FormatInt(id, 10)
+}
+
+func newTweetJS(et *model.EraseTweet) tweetJS {
+	t := rawTweet(et)
+	id := strconv.FormatUint(et.TwitterTweetID, 10)
+	tj := tweetJS{ID: id, IDStr: id, FullText: et.Tweet, CreatedAt: et.PostedAt.UTC().Format(time.RubyDate),
+		Source: t.Source, InReplyToStatusID: formatArchiveID(t.InReplyToStatusID),
+		InReplyToStatusIDStr: formatArchiveID(t.InReplyToStatusID), InReplyToUserID: formatArchiveID(t.InReplyToUserID),
+		InReplyToUserIDStr: formatArchiveID(t.InReplyToUserID), InReplyToScreenName: t.InReplyToScreenName,
+		Lang: t.Lang, FavoriteCount: strconv.Itoa(t.FavoriteCount), RetweetCount: strconv.Itoa(t.RetweetCount),
+		Entities: t.Entities}
+	if len(t.ExtendedEntities.Media) > 0 {
+		tj.ExtendedEntities = &t.ExtendedEntities
+	}
+
+	return tj
+}
+
+// writeTweetJS writes the erase tweets as tweets.js, which is read by readArchiveJS.
+func writeTweetJS(w io.Writer, ets []*model.EraseTweet) error {
+	type item struct {
+		Tweet tweetJS `json:"tweet"`
+	}
+
+	items := make([]item, len(ets))
+	for i, et := range ets {
+		items[i] = item{Tweet: newTweetJS(et)}
+	}
+
+	b, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, "window.YTD.tweet.part0 = "); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
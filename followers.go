@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/ChimeraCoder/anaconda"
+	log "github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+)
+
+// removeFollowers removes followers matched with all specified filters by block and unblock.
+// Users in the keep list are always excluded.
+func (c tweetEraseClient) removeFollowers() error {
+	if c.removeFollowerService == nil {
+		return errors.New("Followers requires mysql database.")
+	}
+
+	if err := c.unblockPendingFollowers(); err != nil {
+		return err
+	}
+
+	kl, err := readKeepList(*followersKeepFile)
+	if err != nil {
+		return err
+	}
+
+	followers, err := c.followers()
+	if err != nil {
+		return err
+	}
+
+	createdAfter := time.Now().AddDate(0, 0, -*createdWithinDays)
+
+	followersMap := map[uint64]anaconda.User{}
+	var ids []uint64
+	for _, f := range followers {
+		if kl.contains(f) {
+			continue
+		} else if *defaultAvatar && !f.DefaultProfileImage {
+			continue
+		}
+
+		if *createdWithinDays > 0 {
+			createdAt, err := time.Parse(time.RubyDate, f.CreatedAt)
+			if err != nil {
+				return err
+			} else if createdAt.Before(createdAfter) {
+				continue
+			}
+		}
+
+		followersMap[uint64(f.Id)] = f
+		ids = append(ids, uint64(f.Id))
+	}
+
+	// The removed followers who follow again are removed again, so the removed followers are not excluded.
+	return c.eraseIDs(ids, func(id uint64, wg *sync.WaitGroup) {
+		c.removeFollower(followersMap[id], wg)
+	})
+}
+
+// unblockPendingFollowers retries the unblock of the followers which remain blocked by the failed unblock.
+func (c tweetEraseClient) unblockPendingFollowers() error {
+	rfs, err := c.removeFollowerService.PendingUnblocks(c.user.UserID)
+	if err != nil {
+		return err
+	}
+
+	rfsMap := map[uint64]*model.RemoveFollower{}
+	var ids []uint64
+	for _, rf := range rfs {
+		rfsMap[rf.ID] = rf
+		ids = append(ids, rf.ID)
+	}
+
+	return c.eraseIDs(ids, func(id uint64, wg *sync.WaitGroup) {
+		defer wg.Done()
+
+		rf := rfsMap[id]
+		l := log.WithFields(log.Fields{"user_id": rf.FollowerTwitterUserID, "screen_name": rf.FollowerScreenName})
+
+		// Create api.
+		api, err := newAPI(c.config)
+		if err != nil {
+			l.Errorf("Fail create api: %s", err)
+			return
+		}
+		defer api.Close()
+
+		c.unblockFollower(api, rf, l)
+	})
+}
+
+func (c tweetEraseClient) followers() ([]anaconda.User, error) {
+	v := url.Values{}
+	v.Set("user_id", fmt.Sprint(c.user.UserID))
+	v.Set("count", fmt.Sprint(200))
+	v.Set("skip_status", "true")
+	v.Set("include_user_entities", "false")
+	v.Set("cursor", "-1")
+
+	var users []anaconda.User
+	for {
+		cursor, err := c.api.GetFollowersList(v)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, cursor.Users...)
+		if cursor.Next_cursor_str == "0" || cursor.Next_cursor_str == "" {
+			return users, nil
+		}
+
+		v.Set("cursor", cursor.Next_cursor_str)
+	}
+}
+
+func (c tweetEraseClient) removeFollower(u anaconda.User, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	l := log.WithFields(log.Fields{"user_id": u.Id, "screen_name": u.ScreenName})
+
+	// Create api.
+	api, err := newAPI(c.config)
+	if err != nil {
+		l.Errorf("Fail create api: %s", err)
+		return
+	}
+	defer api.Close()
+
+	// Record the pending unblock before the block, so the next run unblocks the user if the unblock fails.
+	rf := &model.RemoveFollower{TwitterUserID: c.user.UserID, FollowerTwitterUserID: uint64(u.Id),
+		FollowerScreenName: u.ScreenName, Status: model.RemoveFollowerStatusPendingUnblock}
+	insertID, err := c.removeFollowerService.Insert(rf)
+	if err != nil {
+		l.Errorf("Fail remove follower insert: %s", err)
+		return
+	}
+
+	rf.ID = insertID
+	l = l.WithField("insert_id", insertID)
+
+	v := url.Values{}
+	v.Set("include_entities", "false")
+	v.Set("skip_status", "true")
+	if _, err := api.BlockUserId(u.Id, v); err != nil {
+		l.Errorf("Fail block: %s", err)
+		if err := c.removeFollowerService.Delete(insertID); err != nil {
+			l.Errorf("Fail remove follower delete: %s", err)
+		}
+
+		return
+	}
+
+	c.unblockFollower(api, rf, l)
+}
+
+// unblockFollower unblocks the blocked follower and marks it removed.
+// If the unblock fails, the follower remains pending unblock and is unblocked by the next run.
+func (c tweetEraseClient) unblockFollower(api *anaconda.TwitterApi, rf *model.RemoveFollower, l *log.Entry) {
+	v := url.Values{}
+	v.Set("include_entities", "false")
+	v.Set("skip_status", "true")
+	if _, err := api.UnblockUserId(int64(rf.FollowerTwitterUserID), v); err != nil {
+		l.Errorf("Fail unblock. The user remains blocked until the next run: %s", err)
+		return
+	}
+
+	if err := c.removeFollowerService.UpdateStatus(rf.ID, model.RemoveFollowerStatusRemoved); err != nil {
+		l.Errorf("Fail remove follower update: %s", err)
+		return
+	}
+
+	l.Info("Successfully removed follower!")
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/ChimeraCoder/anaconda"
+	log "github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+)
+
+// keepList is the users never to be removed.
+type keepList struct {
+	ids         map[uint64]struct{}
+	screenNames map[string]struct{}
+}
+
+// readKeepList reads the keep list file of user ids or screen names, one per line.
+// Empty lines and lines starting with # are ignored.
+func readKeepList(path string) (keepList, error) {
+	kl := keepList{ids: map[uint64]struct{}{}, screenNames: map[string]struct{}{}}
+	if path == "" {
+		return kl, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return keepList{}, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if id, err := strconv.ParseUint(line, 10, 64); err == nil {
+			kl.ids[id] = struct{}{}
+			continue
+		}
+
+		kl.screenNames[strings.ToLower(strings.TrimPrefix(line, "@"))] = struct{}{}
+	}
+
+	if err := s.Err(); err != nil {
+		return keepList{}, err
+	}
+
+	return kl, nil
+}
+
+func (kl keepList) contains(u anaconda.User) bool {
+	if _, ok := kl.ids[uint64(u.Id)]; ok {
+		return true
+	}
+
+	_, ok := kl.screenNames[strings.ToLower(u.ScreenName)]
+	return ok
+}
+
+func (c tweetEraseClient) followings() error {
+	if c.followingSnapshotService == nil {
+		return errors.New("Followings requires mysql database.")
+	} else if *refollowSnapshotID != 0 {
+		return c.refollow(*refollowSnapshotID)
+	}
+
+	friends, err := c.friends()
+	if err != nil {
+		return err
+	}
+
+	snapshotID, err := c.snapshotFollowings(friends)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"snapshot_id": snapshotID,
+		"count": len(friends)}).Info("Took followings snapshot.")
+
+	ids, err := c.unfollowTargetIDs(friends)
+	if err != nil {
+		return err
+	}
+
+	return c.eraseIDs(ids, func(id uint64, wg *sync.WaitGroup) {
+		c.unfollow(snapshotID, id, wg)
+	})
+}
+
+func (c tweetEraseClient) friends() ([]anaconda.User, error) {
+	v := url.Values{}
+	v.Set("user_id", fmt.Sprint(c.user.UserID))
+	v.Set("count", fmt.Sprint(200))
+	v.Set("skip_status", "false")
+	v.Set("include_user_entities", "false")
+	v.Set("cursor", "-1")
+
+	var users []anaconda.User
+	for {
+		cursor, err := c.api.GetFriendsList(v)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, cursor.Users...)
+		if cursor.Next_cursor_str == "0" || cursor.Next_cursor_str == "" {
+			return users, nil
+		}
+
+		v.Set("cursor", cursor.Next_cursor_str)
+	}
+}
+
+func (c tweetEraseClient) snapshotFollowings(friends []anaconda.User) (uint64, error) {
+	ids := make([]uint64, len(friends))
+	for i, f := range friends {
+		tu := &model.TwitterUser{UserID: uint64(f.Id),
+			ScreenName: f.ScreenName, Name: f.Name, Lang: f.Lang}
+		if err := c.twitterUserService.InsertUpdate(tu); err != nil {
+			return 0, err
+		}
+
+		ids[i] = tu.UserID
+	}
+
+	return c.followingSnapshotService.Insert(&model.FollowingSnapshot{TwitterUserID: c.user.UserID}, ids)
+}
+
+// unfollowTargetIDs returns the friend ids matched with all specified rules.
+// Users in the keep list are always excluded.
+func (c tweetEraseClient) unfollowTargetIDs(friends []anaconda.User) ([]uint64, error) {
+	kl, err := readKeepList(*followingsKeepFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var interacted map[uint64]struct{}
+	if *neverInteracted {
+		interacted, err = c.interactedUserIDs()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	inactiveBefore := time.Now().AddDate(0, 0, -*inactiveDays)
+
+	var ids []uint64
+	for _, f := range friends {
+		if kl.contains(f) {
+			continue
+		}
+
+		if *inactiveDays > 0 && f.Status != nil {
+			lastTweetedAt, err := f.Status.CreatedAtTime()
+			if err != nil {
+				return nil, err
+			} else if lastTweetedAt.After(inactiveBefore) {
+				continue
+			}
+		}
+
+		if _, ok := interacted[uint64(f.Id)]; ok {
+			continue
+		}
+
+		ids = append(ids, uint64(f.Id))
+	}
+
+	return ids, nil
+}
+
+// interactedUserIDs returns the user ids that the user replied to, retweeted, quoted or mentioned
+// in the ingested archive and the timeline.
+func (c tweetEraseClient) interactedUserIDs() (map[uint64]struct{}, error) {
+	interacted := map[uint64]struct{}{}
+	if c.archivedTweetService != nil {
+		ids, err := c.archivedTweetService.InteractedUserIDs(c.user.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			interacted[id] = struct{}{}
+		}
+	}
+
+	err := c.eachTimelineTweet(func(t anaconda.Tweet) {
+		ids := []int64{t.InReplyToUserID}
+		if t.RetweetedStatus != nil {
+			ids = append(ids, t.RetweetedStatus.User.Id)
+		}
+
+		if t.QuotedStatus != nil {
+			ids = append(ids, t.QuotedStatus.User.Id)
+		}
+
+		for _, m := range t.Entities.User_mentions {
+			ids = append(ids, m.Id)
+		}
+
+		for _, id := range ids {
+			if id != 0 {
+				interacted[uint64(id)] = struct{}{}
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return interacted, nil
+}
+
+func (c tweetEraseClient) unfollow(snapshotID, id uint64, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	l := log.WithFields(log.Fields{"snapshot_id": snapshotID, "user_id": id})
+
+	// Create api.
+	api, err := newAPI(c.config)
+	if err != nil {
+		c.countFailure()
+		l.Errorf("Fail create api: %s", err)
+		return
+	}
+	defer api.Close()
+
+	u, err := api.UnfollowUserId(int64(id))
+	if err != nil {
+		c.countFailure()
+		l.Errorf("Fail unfollow: %s", err)
+		return
+	}
+
+	if err := c.followingSnapshotService.UpdateUnfollowed(snapshotID, id); err != nil {
+		l.Errorf("Fail following update: %s", err)
+		return
+	}
+
+	l.WithField("screen_name", u.ScreenName).Info("Successfully unfollowed!")
+}
+
+// refollow follows again the unfollowed users of the snapshot.
+func (c tweetEraseClient) refollow(snapshotID uint64) error {
+	fs, err := c.followingSnapshotService.Followings(c.user.UserID, snapshotID)
+	if err != nil {
+		return err
+	} else if len(fs) == 0 {
+		return errors.Errorf("Snapshot %d is not found.", snapshotID)
+	}
+
+	var ids []uint64
+	for _, f := range fs {
+		if f.Unfollowed {
+			ids = append(ids, f.FollowingTwitterUserID)
+		}
+	}
+
+	return c.eraseIDs(ids, c.follow)
+}
+
+func (c tweetEraseClient) follow(id uint64, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	l := log.WithField("user_id", id)
+
+	// Create api.
+	api, err := newAPI(c.config)
+	if err != nil {
+		l.Errorf("Fail create api: %s", err)
+		return
+	}
+	defer api.Close()
+
+	u, err := api.FollowUserId(int64(id), nil)
+	if err != nil {
+		l.Errorf("Fail follow: %s", err)
+		return
+	}
+
+	l.WithField("screen_name", u.ScreenName).Info("Successfully followed!")
+}
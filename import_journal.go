@@ -0,0 +1,123 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"time"
+
+	"github.com/178inaba/tweeraser/config"
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/journal"
+	"github.com/178inaba/tweeraser/model/mysql"
+	"github.com/178inaba/tweeraser/model/postgres"
+	"github.com/178inaba/tweeraser/model/sqlite"
+	log "github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+)
+
+// importJournal imports the erase tweets and errors of the journal into the database without twitter api.
+// The runs of the journal are not imported, so the imported rows are out of a run.
+// The journal directory is moved aside after the import, so the rows are not imported twice.
+func importJournal() error {
+	conf, err := config.LoadConfig(configFilePath)
+	if err != nil {
+		return err
+	}
+
+	db, err := newDB(conf.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := checkSchemaVersion(newSchemaMigrationService(conf.Database.Driver, db)); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(*journalDir); os.IsNotExist(err) {
+		log.WithField("journal_dir", *journalDir).Info("No journal to import.")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	jtus, err := journal.NewTwitterUserService(*journalDir)
+	if err != nil {
+		return err
+	}
+
+	jets, err := journal.NewEraseTweetService(*journalDir)
+	if err != nil {
+		return err
+	}
+
+	jees, err := journal.NewEraseErrorService(*journalDir)
+	if err != nil {
+		return err
+	}
+
+	tus := newTwitterUserService(conf.Database.Driver, db)
+	ets, ees := newEraseResultServices(conf.Database.Driver, db)
+	users, err := jtus.TwitterUsers()
+	if err != nil {
+		return err
+	}
+
+	fields := log.Fields{"journal_dir": *journalDir, "users": len(users)}
+	var tweetCnt, errorCnt int
+	for _, tu := range users {
+		if err := tus.InsertUpdate(tu); err != nil {
+			return err
+		}
+
+		ts, err := jets.EraseTweets(tu.UserID)
+		if err != nil {
+			return err
+		}
+
+		for _, et := range ts {
+			et.RunID = 0
+		}
+
+		if err := ets.BulkInsert(ts); err != nil {
+			return errors.Wrapf(err, "import erase tweets of user %d", tu.UserID)
+		}
+
+		es, err := jees.EraseErrors(tu.UserID)
+		if err != nil {
+			return err
+		}
+
+		for _, ee := range es {
+			ee.RunID = 0
+		}
+
+		if err := ees.BulkInsert(es); err != nil {
+			return errors.Wrapf(err, "import erase errors of user %d", tu.UserID)
+		}
+
+		tweetCnt += len(ts)
+		errorCnt += len(es)
+	}
+
+	imported := *journalDir + ".imported-" + time.Now().UTC().Format("20060102150405")
+	if err := os.Rename(*journalDir, imported); err != nil {
+		return err
+	}
+
+	fields["tweets"], fields["errors"], fields["moved_to"] = tweetCnt, errorCnt, imported
+	log.WithFields(fields).Info("Successfully imported journal!")
+	return nil
+}
+
+// newEraseResultServices returns the erase tweet and erase error services of the driver.
+func newEraseResultServices(driver string, db *sql.DB) (model.EraseTweetService, model.EraseErrorService) {
+	switch driver {
+	case config.DriverSQLite:
+		return sqlite.NewEraseTweetService(db), sqlite.NewEraseErrorService(db)
+	case config.DriverPostgres:
+		return postgres.NewEraseTweetService(db), postgres.NewEraseErrorService(db)
+	}
+
+	return mysql.NewEraseTweetService(db), mysql.NewEraseErrorService(db)
+}
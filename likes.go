@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/ChimeraCoder/anaconda"
+	log "github.com/Sirupsen/logrus"
+)
+
+const likeJSFileName = "data/like.js"
+
+type archiveLike struct {
+	Like struct {
+		TweetID string `json:"tweetId"`
+	} `json:"like"`
+}
+
+func (c tweetEraseClient) eraseLikes() error {
+	sources := []func() ([]uint64, error){c.favoritesIDs}
+	if *zipFilePath != "" {
+		sources = append(sources, c.likeJSIDs)
+	}
+
+	ids, err := collectIDs(sources...)
+	if err != nil {
+		return err
+	}
+
+	// Likes are recorded only in mysql.
+	var finders []idsFinder
+	if c.eraseLikeService != nil && c.eraseLikeErrorService != nil {
+		finders = append(finders, c.eraseLikeService.AlreadyEraseLikeIDs, c.eraseLikeErrorService.LikeNotFoundIDs)
+	}
+
+	validIDs, err := c.excludeIDs(ids, finders...)
+	if err != nil {
+		return err
+	}
+
+	return c.eraseIDs(validIDs, c.eraseLike)
+}
+
+func (c tweetEraseClient) likeJSIDs() ([]uint64, error) {
+	rc, err := openZipFile(*zipFilePath, likeJSFileName)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var likes []archiveLike
+	if err := readArchiveJS(rc, &likes); err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint64, 0, len(likes))
+	for _, l := range likes {
+		id, err := strconv.ParseUint(l.Like.TweetID, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (c tweetEraseClient) favoritesIDs() ([]uint64, error) {
+	v := url.Values{}
+	v.Set("user_id", fmt.Sprint(c.user.UserID))
+	v.Set("count", fmt.Sprint(200))
+	v.Set("include_entities", "false")
+
+	var ids []uint64
+	for {
+		tweets, err := c.api.GetFavorites(v)
+		if err != nil {
+			return nil, err
+		} else if len(tweets) == 0 {
+			return ids, nil
+		}
+
+		for _, t := range tweets {
+			ids = append(ids, uint64(t.Id))
+		}
+
+		v.Set("max_id", fmt.Sprint(tweets[len(tweets)-1].Id-1))
+	}
+}
+
+func (c tweetEraseClient) eraseLike(id uint64, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	l := log.WithField("id", id)
+
+	// Create api.
+	api, err := newAPI(c.config)
+	if err != nil {
+		c.countFailure()
+		l.Errorf("Fail create api: %s", err)
+		return
+	}
+	defer api.Close()
+
+	t, err := api.Unfavorite(int64(id))
+	if err != nil {
+		insertID, insertErr := c.insertEraseLikeError(id, err)
+		if insertID != 0 && insertErr == nil {
+			l = l.WithField("insert_id", insertID)
+		} else if insertErr != nil {
+			l.Errorf("Fail erase like error insert: %s", insertErr)
+		}
+
+		c.countFailure()
+		l.Errorf("Fail unfavorite: %s", err)
+		return
+	}
+
+	insertID, err := c.insertEraseLike(t)
+	if err != nil {
+		l.Errorf("Fail erase like insert: %s", err)
+		return
+	} else if insertID != 0 {
+		l = l.WithField("insert_id", insertID)
+	}
+
+	withContent(l, tweetText(t)).Info("Successfully unfavorited!")
+}
+
+func (c tweetEraseClient) insertEraseLike(t anaconda.Tweet) (uint64, error) {
+	if c.eraseLikeService == nil {
+		return 0, nil
+	}
+
+	el := &model.EraseLike{TwitterTweetID: uint64(t.Id),
+		TweetTwitterUserID: uint64(t.User.Id), TwitterUserID: c.user.UserID}
+	// The table has no column for the hash, so the text is kept only with --store-content full.
+	if *storeContent == model.StoreContentFull {
+		el.Tweet = tweetText(t)
+	}
+
+	insertID, err := c.eraseLikeService.Insert(el)
+	if err != nil {
+		return 0, err
+	}
+
+	return insertID, nil
+}
+
+func (c tweetEraseClient) insertEraseLikeError(tweetID uint64, err error) (uint64, error) {
+	if c.eraseLikeErrorService == nil {
+		return 0, nil
+	}
+
+	var statusCode uint16
+	if apiErr, ok := err.(*anaconda.ApiError); ok {
+		statusCode = uint16(apiErr.StatusCode)
+	}
+
+	ele := &model.EraseLikeError{TriedTwitterUserID: c.user.UserID, TwitterTweetID: tweetID, StatusCode: statusCode, ErrorMessage: err.Error()}
+	insertID, err := c.eraseLikeErrorService.Insert(ele)
+	if err != nil {
+		return 0, err
+	}
+
+	return insertID, nil
+}
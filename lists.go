@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/ChimeraCoder/anaconda"
+	log "github.com/Sirupsen/logrus"
+)
+
+type savedSearch struct {
+	ID    int64  `json:"id"`
+	Query string `json:"query"`
+}
+
+func (c tweetEraseClient) ownedLists() ([]anaconda.List, error) {
+	v := url.Values{}
+	v.Set("count", fmt.Sprint(1000))
+	return c.api.GetListsOwnedBy(int64(c.user.UserID), v)
+}
+
+// eraseLists erases the lists owned by the user.
+// In dry-run the lists are only logged.
+func (c tweetEraseClient) eraseLists() error {
+	lists, err := c.ownedLists()
+	if err != nil {
+		return err
+	}
+
+	api := newRawAPI(c.config)
+	for _, list := range lists {
+		l := log.WithFields(log.Fields{"list_id": list.Id, "name": list.Name})
+		if *listsDryRun {
+			l.Info("Dry-run: list will be erased.")
+			continue
+		}
+
+		v := url.Values{}
+		v.Set("list_id", fmt.Sprint(list.Id))
+		if err := api.post("lists/destroy.json", v, nil); err != nil {
+			c.countFailure()
+			l.Errorf("Fail erase list: %s", err)
+			continue
+		}
+
+		insertID, err := c.insertEraseList(list)
+		if err != nil {
+			l.Errorf("Fail erase list insert: %s", err)
+			continue
+		} else if insertID != 0 {
+			l = l.WithField("insert_id", insertID)
+		}
+
+		l.Info("Successfully erased list!")
+	}
+
+	return nil
+}
+
+func (c tweetEraseClient) insertEraseList(list anaconda.List) (uint64, error) {
+	if c.eraseListService == nil {
+		return 0, nil
+	}
+
+	el := &model.EraseList{TwitterListID: uint64(list.Id), Name: list.Name, Mode: list.Mode,
+		Description: list.Description, MemberCount: uint64(list.MemberCount), TwitterUserID: c.user.UserID}
+	insertID, err := c.eraseListService.Insert(el)
+	if err != nil {
+		return 0, err
+	}
+
+	return insertID, nil
+}
+
+func (c tweetEraseClient) savedSearches() ([]savedSearch, error) {
+	var searches []savedSearch
+	if err := newRawAPI(c.config).get("saved_searches/list.json", nil, &searches); err != nil {
+		return nil, err
+	}
+
+	return searches, nil
+}
+
+// eraseSavedSearches erases the saved searches of the user.
+// In dry-run the saved searches are only logged.
+func (c tweetEraseClient) eraseSavedSearches() error {
+	searches, err := c.savedSearches()
+	if err != nil {
+		return err
+	}
+
+	api := newRawAPI(c.config)
+	for _, search := range searches {
+		l := log.WithFields(log.Fields{"saved_search_id": search.ID, "query": search.Query})
+		if *savedSearchesDryRun {
+			l.Info("Dry-run: saved search will be erased.")
+			continue
+		}
+
+		if err := api.post(fmt.Sprintf("saved_searches/destroy/%d.json", search.ID), nil, nil); err != nil {
+			c.countFailure()
+			l.Errorf("Fail erase saved search: %s", err)
+			continue
+		}
+
+		insertID, err := c.insertEraseSavedSearch(search)
+		if err != nil {
+			l.Errorf("Fail erase saved search insert: %s", err)
+			continue
+		} else if insertID != 0 {
+			l = l.WithField("insert_id", insertID)
+		}
+
+		l.Info("Successfully erased saved search!")
+	}
+
+	return nil
+}
+
+func (c tweetEraseClient) insertEraseSavedSearch(search savedSearch) (uint64, error) {
+	if c.eraseSavedSearchService == nil {
+		return 0, nil
+	}
+
+	ess := &model.EraseSavedSearch{TwitterSavedSearchID: uint64(search.ID),
+		Query: search.Query, TwitterUserID: c.user.UserID}
+	insertID, err := c.eraseSavedSearchService.Insert(ess)
+	if err != nil {
+		return 0, err
+	}
+
+	return insertID, nil
+}
@@ -1,96 +1,365 @@
 package main
 
 import (
-	"archive/zip"
 	"database/sql"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/url"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 
 	"github.com/178inaba/tweeraser/config"
 	"github.com/178inaba/tweeraser/model"
 	"github.com/178inaba/tweeraser/model/mysql"
+	"github.com/178inaba/tweeraser/model/postgres"
+	"github.com/178inaba/tweeraser/model/sqlite"
 	"github.com/ChimeraCoder/anaconda"
 	log "github.com/Sirupsen/logrus"
 	"github.com/pkg/errors"
 )
 
-const configFilePath = "etc/config.toml"
+const (
+	configFilePath = "etc/config.toml"
+
+	// timelineLimit is the max number of tweets the user timeline api can return.
+	timelineLimit = 3200
+
+	// Erase tweets and errors are written by bulk insert of the batch size or every flush interval.
+	eraseResultBatchSize     = 500
+	eraseResultFlushInterval = 3 * time.Second
+)
+
+// errInterrupted is returned when the erases are stopped by the signal.
+var errInterrupted = errors.New("Interrupted by signal.")
+
+// version is the tool version recorded in the erase runs. Release builds set it by -ldflags "-X main.version=...".
+var version = "dev"
 
 var (
 	csvFilePath = kingpin.Flag("csv-file", "all tweets csv file (tweets.csv) path.").String()
 	zipFilePath = kingpin.Flag("zip-file", "all tweets zip file path.").String()
+
+	journalDir       = kingpin.Flag("journal-dir", "directory of the jsonl journal used when no database is available.").Default("journal").String()
+	backupMediaDir   = kingpin.Flag("backup-media", "directory to save photos, gifs and videos of tweets before erasing. requires --store-content full.").String()
+	storedUserID     = kingpin.Flag("user-id", "twitter user id of the stored data for stats, export, render and purge-content. default is the only stored user.").Uint64()
+	storedScreenName = kingpin.Flag("screen-name", "screen name of the stored twitter user for stats, export, render and purge-content.").String()
+	storeContent     = kingpin.Flag("store-content", "content of erased tweets to store. none keeps only ids and dates, hash keeps the salted hash of the text also.").
+				Default(model.StoreContentFull).Enum(model.StoreContentNone, model.StoreContentHash, model.StoreContentFull)
+
+	eraseCmd       = kingpin.Command("erase", "erase tweets.").Default()
+	eraseQuery     = eraseCmd.Flag("query", "sql query selecting tweet ids to erase (e.g. SELECT twitter_tweet_id FROM archived_tweets WHERE ...).").String()
+	eraseQueryFile = eraseCmd.Flag("query-file", "sql file path of query selecting tweet ids to erase.").String()
+	withTimeline   = eraseCmd.Flag("with-timeline", "erase the timeline tweets together with --csv-file or --zip-file tweets.").Bool()
+	eraseSearch    = eraseCmd.Flag("search", "search tweets by from:<screen_name> with the premium full-archive search (search_env of the config) to reach tweets beyond the timeline limit.").Bool()
+	searchSince    = eraseCmd.Flag("search-since", "search start date (YYYY-MM-DD). default is the account created date.").String()
+	searchWindow   = eraseCmd.Flag("search-window-days", "number of days of one search window.").Default("30").Int()
+
+	likesCmd = kingpin.Command("likes", "erase likes (unfavorite) of favorites and archive like.js (--zip-file).")
+
+	dmsCmd            = kingpin.Command("dms", "erase direct messages of the events api and archive direct-messages.js (--zip-file).")
+	dmConversationIDs = dmsCmd.Flag("conversation", "conversation id to erase. can be repeated. default is all conversations.").Strings()
+	dmStoreMessage    = dmsCmd.Flag("store-message", "store the text of erased direct messages. default stores only the ids, the participants and the sent time. ignored with --store-content none or hash.").Bool()
+
+	followingsCmd      = kingpin.Command("followings", "take followings snapshot and unfollow all or the followings matched with all rules.")
+	inactiveDays       = followingsCmd.Flag("inactive-days", "unfollow only users who have not tweeted for the days.").Int()
+	neverInteracted    = followingsCmd.Flag("never-interacted", "unfollow only users never replied to, retweeted, quoted or mentioned.").Bool()
+	followingsKeepFile = followingsCmd.Flag("keep", "keep list file of user ids or screen names never to unfollow.").String()
+	refollowSnapshotID = followingsCmd.Flag("refollow", "follow again the unfollowed users of the snapshot id.").Uint64()
+
+	followersCmd      = kingpin.Command("followers", "remove all or the followers matched with all filters by block and unblock.")
+	createdWithinDays = followersCmd.Flag("created-within-days", "remove only users whose account was created within the days.").Int()
+	defaultAvatar     = followersCmd.Flag("default-avatar", "remove only users with the default profile image.").Bool()
+	followersKeepFile = followersCmd.Flag("keep", "keep list file of user ids or screen names never to remove.").String()
+
+	listsCmd    = kingpin.Command("lists", "erase lists owned by the user.")
+	listsDryRun = listsCmd.Flag("dry-run", "only show the lists to erase.").Bool()
+
+	savedSearchesCmd    = kingpin.Command("saved-searches", "erase saved searches.")
+	savedSearchesDryRun = savedSearchesCmd.Flag("dry-run", "only show the saved searches to erase.").Bool()
+
+	wipeCmd        = kingpin.Command("wipe", "wipe account in order of "+strings.Join(wipeStageNames, ", ")+". resume the unfinished job.")
+	wipeSkipStages = wipeCmd.Flag("skip", "stage to skip. can be repeated.").Enums(wipeStageNames...)
+	wipeRestart    = wipeCmd.Flag("restart", "start new job instead of resuming the unfinished job.").Bool()
+
+	migrateCmd       = kingpin.Command("migrate", "migrate database schema.")
+	migrateUpCmd     = migrateCmd.Command("up", "apply all pending migrations.")
+	migrateDownCmd   = migrateCmd.Command("down", "roll back the latest applied migration.")
+	migrateStatusCmd = migrateCmd.Command("status", "show the migrations and the applied time.")
+
+	importJournalCmd = kingpin.Command("import-journal", "import erased tweets and errors of --journal-dir into the database and move the journal aside.")
+
+	ingestCmd         = kingpin.Command("ingest", "ingest all tweets archive into database without erasing.")
+	ingestCsvFilePath = ingestCmd.Flag("csv", "all tweets csv file (tweets.csv) path.").String()
+	ingestZipFilePath = ingestCmd.Flag("zip", "all tweets zip file path.").String()
+
+	statsCmd    = kingpin.Command("stats", "show erased tweets by month, source and error kind, and the remaining candidates.")
+	statsFormat = statsCmd.Flag("format", "output format.").Default(statsFormatTable).Enum(statsFormatTable, statsFormatCSV, statsFormatJSON)
+
+	exportCmd    = kingpin.Command("export", "export erased tweets in the shape of the twitter archive.")
+	exportFormat = exportCmd.Flag("format", "output format.").Default(exportFormatCSV).Enum(exportFormatCSV, exportFormatJSON, exportFormatTweetJS)
+	exportOut    = exportCmd.Flag("out", "output file path. default is stdout.").String()
+
+	renderCmd = kingpin.Command("render", "render erased tweets into a static html site with the page per month and search.")
+	renderOut = renderCmd.Flag("out", "output directory of the site.").Required().String()
+
+	purgeContentCmd = kingpin.Command("purge-content", "scrub the text of the stored erased tweets by --store-content none or hash.")
 )
 
 func main() {
-	kingpin.Parse()
-	os.Exit(run())
+	kingpin.Version(version)
+	cmd := kingpin.Parse()
+	os.Exit(run(cmd))
 }
 
-func run() int {
-	c, err := newTweetEraseClient()
+func run(cmd string) int {
+	var err error
+	switch cmd {
+	case migrateUpCmd.FullCommand(), migrateDownCmd.FullCommand(), migrateStatusCmd.FullCommand():
+		err = migrate(cmd)
+	case importJournalCmd.FullCommand():
+		err = importJournal()
+	default:
+		err = runClient(cmd)
+	}
 	if err != nil {
 		log.Error(err)
 		return 1
 	}
-	defer c.close()
 
-	if *csvFilePath != "" {
-		err = c.eraseCsv()
-	} else if *zipFilePath != "" {
-		err = c.eraseZip()
-	} else {
-		err = c.eraseTimeline()
+	return 0
+}
+
+func runClient(cmd string) error {
+	if *backupMediaDir != "" && *storeContent != model.StoreContentFull {
+		return errors.New("Backup media requires --store-content full.")
 	}
+
+	c, err := newTweetEraseClient(cmd)
 	if err != nil {
-		log.Error(err)
-		return 1
+		return err
 	}
+	defer c.close()
 
-	return 0
+	c.command = cmd
+	defer c.stopOnSignal()()
+
+	switch cmd {
+	case ingestCmd.FullCommand():
+		err = c.ingest()
+	case eraseCmd.FullCommand():
+		err = c.erase()
+	case likesCmd.FullCommand():
+		err = c.eraseLikes()
+	case dmsCmd.FullCommand():
+		err = c.eraseDirectMessages()
+	case followingsCmd.FullCommand():
+		err = c.followings()
+	case followersCmd.FullCommand():
+		err = c.removeFollowers()
+	case listsCmd.FullCommand():
+		err = c.eraseLists()
+	case savedSearchesCmd.FullCommand():
+		err = c.eraseSavedSearches()
+	case wipeCmd.FullCommand():
+		err = c.wipe()
+	case statsCmd.FullCommand():
+		err = c.stats()
+	case exportCmd.FullCommand():
+		err = c.export()
+	case renderCmd.FullCommand():
+		err = c.render()
+	case purgeContentCmd.FullCommand():
+		err = c.purgeContent()
+	}
+
+	return err
 }
 
-func newTweetEraseClient() (*tweetEraseClient, error) {
-	conf, err := config.LoadConfig(configFilePath)
-	if err != nil {
-		return nil, err
+// isLocalCommand reports whether the command reads only the stored data.
+// The local command takes the user from the stored twitter users instead of the twitter api, so it needs no credentials.
+func isLocalCommand(cmd string) bool {
+	switch cmd {
+	case statsCmd.FullCommand(), exportCmd.FullCommand(), renderCmd.FullCommand(), purgeContentCmd.FullCommand():
+		return true
 	}
 
-	api, err := newAPI(conf)
+	return false
+}
+
+// requiresMySQL reports whether the command uses the services implemented only in mysql.
+func requiresMySQL(cmd string) bool {
+	switch cmd {
+	case likesCmd.FullCommand(), dmsCmd.FullCommand(), followingsCmd.FullCommand(), followersCmd.FullCommand(),
+		listsCmd.FullCommand(), savedSearchesCmd.FullCommand(), wipeCmd.FullCommand(), ingestCmd.FullCommand():
+		return true
+	case eraseCmd.FullCommand():
+		return *eraseQuery != "" || *eraseQueryFile != ""
+	}
+
+	return false
+}
+
+func newTweetEraseClient(cmd string) (*tweetEraseClient, error) {
+	conf, err := config.LoadConfig(configFilePath)
 	if err != nil {
 		return nil, err
+	} else if *storeContent == model.StoreContentHash && conf.ContentHashSalt == "" {
+		return nil, errors.New("Set content_hash_salt in the config to store the hash of tweets.")
+	} else if requiresMySQL(cmd) && conf.Database.Driver != config.DriverMySQL {
+		return nil, errors.Errorf("%s requires the mysql driver, %s is not supported.", cmd, conf.Database.Driver)
 	}
 
 	var ets model.EraseTweetService
 	var ees model.EraseErrorService
-	db, err := newDB()
+	var ers model.EraseRunService
+	var ats model.ArchivedTweetService
+	var qs model.QueryService
+	var els model.EraseLikeService
+	var eles model.EraseLikeErrorService
+	var edms model.EraseDirectMessageService
+	var wjs model.WipeJobService
+	var fss model.FollowingSnapshotService
+	var rfs model.RemoveFollowerService
+	var elss model.EraseListService
+	var esss model.EraseSavedSearchService
+	var tus model.TwitterUserService
+	db, err := newDB(conf.Database)
 	if err == nil {
+		if err := checkSchemaVersion(newSchemaMigrationService(conf.Database.Driver, db)); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	switch {
+	case err != nil:
+		log.WithField("journal_dir", *journalDir).Warnf("Fall back to journal, run import-journal when the database is back: %s", err)
+		js, err := newJournalServices(*journalDir)
+		if err != nil {
+			return nil, err
+		}
+
+		ets, ees, ers, tus = js.eraseTweetService, js.eraseErrorService, js.eraseRunService, js.twitterUserService
+	case conf.Database.Driver == config.DriverSQLite:
+		// SQLite and PostgreSQL backends store only erase tweets, errors and runs.
+		ets = sqlite.NewEraseTweetService(db)
+		ees = sqlite.NewEraseErrorService(db)
+		ers = sqlite.NewEraseRunService(db)
+	case conf.Database.Driver == config.DriverPostgres:
+		ets = postgres.NewEraseTweetService(db)
+		ees = postgres.NewEraseErrorService(db)
+		ers = postgres.NewEraseRunService(db)
+	default:
 		ets = mysql.NewEraseTweetService(db)
 		ees = mysql.NewEraseErrorService(db)
+		ers = mysql.NewEraseRunService(db)
+		els = mysql.NewEraseLikeService(db)
+		eles = mysql.NewEraseLikeErrorService(db)
+		edms = mysql.NewEraseDirectMessageService(db)
+		wjs = mysql.NewWipeJobService(db)
+		fss = mysql.NewFollowingSnapshotService(db)
+		rfs = mysql.NewRemoveFollowerService(db)
+		elss = mysql.NewEraseListService(db)
+		esss = mysql.NewEraseSavedSearchService(db)
+		ats = mysql.NewArchivedTweetService(db)
+		qs = mysql.NewQueryService(db)
+	}
+
+	if tus == nil {
+		tus = newTwitterUserService(conf.Database.Driver, db)
+	}
+
+	var api *anaconda.TwitterApi
+	var tu *model.TwitterUser
+	if isLocalCommand(cmd) {
+		tu, err = storedTwitterUser(tus)
+		if err != nil {
+			return nil, err
+		}
 	} else {
-		log.Warn(err)
+		api, err = newAPI(conf)
+		if err != nil {
+			return nil, err
+		}
+
+		// Create twitter user.
+		tu, err = model.NewTwitterUser(api)
+		if err != nil {
+			return nil, err
+		}
+
+		// Insert twitter user.
+		err = tus.InsertUpdate(tu)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// Create twitter user.
-	tu, err := model.NewTwitterUser(api)
-	if err != nil {
-		return nil, err
+	var erw *model.EraseResultWriter
+	if ets != nil && ees != nil {
+		erw = model.NewEraseResultWriter(ets, ees, eraseResultBatchSize, eraseResultFlushInterval, func(err error) {
+			log.Errorf("Fail erase result write: %s", err)
+		})
+
+		// The erase results the database still fails to write on close are kept in the journal.
+		if db != nil {
+			erw.SetFallback(func() (model.EraseTweetService, model.EraseErrorService, error) {
+				log.WithField("journal_dir", *journalDir).Warn("Write the rest of erase results to journal. Run import-journal to import them into the database.")
+				js, err := newJournalServices(*journalDir)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				return js.eraseTweetService, js.eraseErrorService, nil
+			})
+		}
 	}
 
-	// Insert twitter user.
-	err = mysql.NewTwitterUserService(db).InsertUpdate(tu)
+	return &tweetEraseClient{stop: make(chan struct{}), failures: new(uint64), config: conf, api: api, user: tu, db: db, eraseResultWriter: erw,
+		eraseTweetService: ets, eraseErrorService: ees, eraseRunService: ers, archivedTweetService: ats, queryService: qs,
+		eraseLikeService: els, eraseLikeErrorService: eles, eraseDirectMessageService: edms,
+		wipeJobService: wjs, twitterUserService: tus, followingSnapshotService: fss,
+		removeFollowerService: rfs, eraseListService: elss, eraseSavedSearchService: esss}, nil
+}
+
+// storedTwitterUser returns the stored twitter user of --user-id or --screen-name.
+// Without the flags the only stored user is returned.
+func storedTwitterUser(tus model.TwitterUserService) (*model.TwitterUser, error) {
+	users, err := tus.TwitterUsers()
 	if err != nil {
 		return nil, err
 	}
 
-	return &tweetEraseClient{
-		config: conf, api: api, user: tu, db: db, eraseTweetService: ets, eraseErrorService: ees}, nil
+	var matched []*model.TwitterUser
+	for _, tu := range users {
+		if *storedUserID != 0 && tu.UserID != *storedUserID {
+			continue
+		} else if *storedScreenName != "" && !strings.EqualFold(tu.ScreenName, *storedScreenName) {
+			continue
+		}
+
+		matched = append(matched, tu)
+	}
+
+	switch len(matched) {
+	case 0:
+		return nil, errors.New("No stored twitter user. Run erase first or check --user-id and --screen-name.")
+	case 1:
+		return matched[0], nil
+	default:
+		return nil, errors.New("Multiple twitter users are stored. Specify --user-id or --screen-name.")
+	}
 }
 
 func newAPI(conf *config.Config) (*anaconda.TwitterApi, error) {
@@ -99,71 +368,165 @@ func newAPI(conf *config.Config) (*anaconda.TwitterApi, error) {
 	return anaconda.NewTwitterApi(conf.AccessToken, conf.AccessTokenSecret), nil
 }
 
-func newDB() (*sql.DB, error) {
-	db, err := mysql.Open("root", "", "tweeraser")
-	if err != nil {
-		return nil, errors.Errorf("Fail db open: %s.", err)
+type tweetEraseClient struct {
+	command                   string
+	stop                      chan struct{}
+	failures                  *uint64
+	config                    *config.Config
+	api                       *anaconda.TwitterApi
+	user                      *model.TwitterUser
+	db                        *sql.DB
+	eraseResultWriter         *model.EraseResultWriter
+	eraseTweetService         model.EraseTweetService
+	eraseErrorService         model.EraseErrorService
+	eraseRunService           model.EraseRunService
+	archivedTweetService      model.ArchivedTweetService
+	queryService              model.QueryService
+	eraseLikeService          model.EraseLikeService
+	eraseLikeErrorService     model.EraseLikeErrorService
+	eraseDirectMessageService model.EraseDirectMessageService
+	wipeJobService            model.WipeJobService
+	twitterUserService        model.TwitterUserService
+	followingSnapshotService  model.FollowingSnapshotService
+	removeFollowerService     model.RemoveFollowerService
+	eraseListService          model.EraseListService
+	eraseSavedSearchService   model.EraseSavedSearchService
+}
+
+func (c tweetEraseClient) erase() error {
+	if *eraseQuery != "" || *eraseQueryFile != "" {
+		return c.eraseQuery()
 	}
 
-	if err := db.Ping(); err != nil {
-		return nil, errors.Errorf("Fail db ping: %s.", err)
+	var sources []func() ([]uint64, error)
+	var sourceNames []string
+	if *csvFilePath != "" {
+		sources = append(sources, c.csvFileIDs)
+		sourceNames = append(sourceNames, "csv")
+	} else if *zipFilePath != "" {
+		sources = append(sources, c.zipFileIDs)
+		sourceNames = append(sourceNames, "zip")
 	}
 
-	if err := mysql.SetMaxOpenConnsFromDB(db, 50); err != nil {
-		return nil, err
+	filters := map[string]interface{}{}
+	if *eraseSearch {
+		sources = append(sources, c.searchIDs)
+		sourceNames = append(sourceNames, "search")
+		filters["search_since"] = *searchSince
+		filters["search_window_days"] = *searchWindow
+	}
+
+	// Timeline is default source.
+	if len(sources) == 0 || *withTimeline {
+		sources = append(sources, c.timelineIDs)
+		sourceNames = append(sourceNames, "timeline")
 	}
 
-	return db, nil
+	ids, err := collectIDs(sources...)
+	if err != nil {
+		return err
+	}
+
+	return c.checkBeforeEraseIDs(ids, sourceNames, filters)
 }
 
-type tweetEraseClient struct {
-	config            *config.Config
-	api               *anaconda.TwitterApi
-	user              *model.TwitterUser
-	db                *sql.DB
-	eraseTweetService model.EraseTweetService
-	eraseErrorService model.EraseErrorService
+// collectIDs concatenates the ids of all sources.
+// Duplicate ids are merged in excludeIDs.
+func collectIDs(sources ...func() ([]uint64, error)) ([]uint64, error) {
+	var ids []uint64
+	for _, source := range sources {
+		sourceIDs, err := source()
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, sourceIDs...)
+	}
+
+	return ids, nil
 }
 
-func (c tweetEraseClient) eraseCsv() error {
-	f, err := os.Open(*csvFilePath)
+func (c tweetEraseClient) ingest() error {
+	if c.archivedTweetService == nil {
+		return errors.New("Ingest requires mysql database.")
+	}
+
+	var rc io.ReadCloser
+	var err error
+	if *ingestCsvFilePath != "" {
+		rc, err = os.Open(*ingestCsvFilePath)
+	} else if *ingestZipFilePath != "" {
+		rc, err = openZipFile(*ingestZipFilePath, tweetsCsvFileName)
+	} else {
+		return errors.New("Specify --csv or --zip.")
+	}
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-
-	return c.eraseCsvReader(f)
-}
+	defer rc.Close()
 
-func (c tweetEraseClient) eraseZip() error {
-	r, err := zip.OpenReader(*zipFilePath)
+	ats, err := readArchiveCsv(rc, c.user.UserID)
 	if err != nil {
 		return err
 	}
-	defer r.Close()
 
-	var zf *zip.File
-	for _, f := range r.File {
-		if f.Name == "tweets.csv" {
-			zf = f
-			break
+	if err := c.archivedTweetService.BulkInsertUpdate(ats); err != nil {
+		return err
+	}
+
+	log.WithField("count", len(ats)).Info("Successfully ingested!")
+	return nil
+}
+
+func (c tweetEraseClient) eraseQuery() error {
+	if c.queryService == nil {
+		return errors.New("Query requires mysql database.")
+	}
+
+	query := *eraseQuery
+	if *eraseQueryFile != "" {
+		b, err := ioutil.ReadFile(*eraseQueryFile)
+		if err != nil {
+			return err
 		}
+
+		query = string(b)
 	}
 
-	rc, err := zf.Open()
+	ids, err := c.queryService.QueryTweetIDs(query)
 	if err != nil {
 		return err
 	}
+
+	log.WithField("count", len(ids)).Info("Selected tweet ids by query.")
+	return c.checkBeforeEraseIDs(ids, []string{"query"}, map[string]interface{}{"query": query})
+}
+
+func (c tweetEraseClient) csvFileIDs() ([]uint64, error) {
+	f, err := os.Open(*csvFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return csvIDs(f)
+}
+
+func (c tweetEraseClient) zipFileIDs() ([]uint64, error) {
+	rc, err := openZipFile(*zipFilePath, tweetsCsvFileName)
+	if err != nil {
+		return nil, err
+	}
 	defer rc.Close()
 
-	return c.eraseCsvReader(rc)
+	return csvIDs(rc)
 }
 
-func (c tweetEraseClient) eraseCsvReader(r io.Reader) error {
+func csvIDs(r io.Reader) ([]uint64, error) {
 	cr := csv.NewReader(r)
 	header, err := cr.Read()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var tweetIDIndex int
@@ -178,44 +541,69 @@ func (c tweetEraseClient) eraseCsvReader(r io.Reader) error {
 	for {
 		record, err := cr.Read()
 		if err == io.EOF {
-			return c.checkBeforeEraseIDs(ids)
+			return ids, nil
 		} else if err != nil {
-			return err
+			return nil, err
 		}
 
 		id, err := strconv.ParseInt(record[tweetIDIndex], 10, 64)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		ids = append(ids, uint64(id))
 	}
 }
 
-func (c tweetEraseClient) checkBeforeEraseIDs(ids []uint64) error {
+// checkBeforeEraseIDs erases the ids not erased yet in an erase run of the sources and filters.
+func (c tweetEraseClient) checkBeforeEraseIDs(ids []uint64, sources []string, filters map[string]interface{}) error {
+	validIDs, err := c.excludeIDs(ids,
+		c.eraseTweetService.AlreadyEraseTweetIDs, c.eraseErrorService.TweetNotFoundIDs)
+	if err != nil {
+		return err
+	}
+
+	run, err := c.startEraseRun(sources, filters)
+	if err != nil {
+		return err
+	}
+
+	run.TargetCount = uint64(len(validIDs))
+	err = c.eraseIDs(validIDs, func(id uint64, wg *sync.WaitGroup) {
+		c.eraseTweet(run, id, wg)
+	})
+	if finishErr := c.finishEraseRun(run); finishErr != nil && err == nil {
+		err = finishErr
+	}
+
+	return err
+}
+
+// idsFinder returns the ids to exclude from argument ids.
+type idsFinder func(userID uint64, ids []uint64) ([]uint64, error)
+
+// excludeIDs removes duplicate ids and ids found by finders.
+func (c tweetEraseClient) excludeIDs(ids []uint64, finders ...idsFinder) ([]uint64, error) {
 	idsMap := map[uint64]struct{}{}
 	for _, id := range ids {
 		idsMap[id] = struct{}{}
 	}
 
 	inCount := 1000
-	for inCount > 0 {
-		tweetIDs, err := c.eraseTweetService.AlreadyEraseTweetIDs(c.user.UserID, ids[:inCount])
-		if err != nil {
-			return err
-		}
-
-		for _, id := range tweetIDs {
-			delete(idsMap, id)
-		}
-
-		notFoundIDs, err := c.eraseErrorService.TweetNotFoundIDs(c.user.UserID, ids[:inCount])
-		if err != nil {
-			return err
-		}
+	if len(ids) < inCount {
+		inCount = len(ids)
+	}
 
-		for _, id := range notFoundIDs {
-			delete(idsMap, id)
+	for inCount > 0 {
+		for _, find := range finders {
+			foundIDs, err := find(c.user.UserID, ids[:inCount])
+			if err != nil {
+				return nil, err
+			}
+
+			for _, id := range foundIDs {
+				delete(idsMap, id)
+			}
 		}
 
 		ids = append(ids[:0], ids[inCount:]...)
@@ -230,10 +618,30 @@ func (c tweetEraseClient) checkBeforeEraseIDs(ids []uint64) error {
 		validIDs = append(validIDs, id)
 	}
 
-	return c.eraseIDs(validIDs)
+	return validIDs, nil
+}
+
+func (c tweetEraseClient) timelineIDs() ([]uint64, error) {
+	return c.filterTimelineIDs(func(anaconda.Tweet) bool { return true })
 }
 
-func (c tweetEraseClient) eraseTimeline() error {
+// filterTimelineIDs returns the user timeline tweet ids matched with filter.
+func (c tweetEraseClient) filterTimelineIDs(filter func(t anaconda.Tweet) bool) ([]uint64, error) {
+	var ids []uint64
+	err := c.eachTimelineTweet(func(t anaconda.Tweet) {
+		if filter(t) {
+			ids = append(ids, uint64(t.Id))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// eachTimelineTweet calls fn for each tweet of the user timeline.
+func (c tweetEraseClient) eachTimelineTweet(fn func(t anaconda.Tweet)) error {
 	v := url.Values{}
 	v.Set("user_id", fmt.Sprint(c.user.UserID))
 	v.Set("count", fmt.Sprint(200))
@@ -241,25 +649,33 @@ func (c tweetEraseClient) eraseTimeline() error {
 	v.Set("exclude_replies", "false")
 	v.Set("contributor_details", "false")
 	v.Set("include_rts", "true")
+	v.Set("tweet_mode", "extended")
 
-	var ids []uint64
+	var cnt int
 	for {
 		tweets, err := c.api.GetUserTimeline(v)
 		if err != nil {
 			return err
 		} else if len(tweets) == 0 {
-			return c.eraseIDs(ids)
+			if cnt >= timelineLimit {
+				log.WithField("count", cnt).Warnf(
+					"Reached the timeline limit of %d tweets. Older tweets remain, use --zip-file.", timelineLimit)
+			}
+
+			return nil
 		}
 
 		for _, t := range tweets {
-			ids = append(ids, uint64(t.Id))
+			cnt++
+			fn(t)
 		}
 
 		v.Set("max_id", fmt.Sprint(tweets[len(tweets)-1].Id-1))
 	}
 }
 
-func (c tweetEraseClient) eraseIDs(ids []uint64) error {
+// eraseIDs runs eraseFunc for each id, up to 1000 goroutines at a time.
+func (c tweetEraseClient) eraseIDs(ids []uint64, eraseFunc func(id uint64, wg *sync.WaitGroup)) error {
 	trialCnt := 1000
 	idsLen := len(ids)
 	if idsLen < 1000 {
@@ -269,11 +685,18 @@ func (c tweetEraseClient) eraseIDs(ids []uint64) error {
 	for trialCnt > 0 {
 		wg := new(sync.WaitGroup)
 		for _, id := range ids[:trialCnt] {
+			if c.stopped() {
+				break
+			}
+
 			wg.Add(1)
-			go c.eraseTweet(id, wg)
+			go eraseFunc(id, wg)
 		}
 
 		wg.Wait()
+		if c.stopped() {
+			return errInterrupted
+		}
 
 		ids = append(ids[:0], ids[trialCnt:]...)
 		idsLen := len(ids)
@@ -285,73 +708,122 @@ func (c tweetEraseClient) eraseIDs(ids []uint64) error {
 	return nil
 }
 
-func (c tweetEraseClient) eraseTweet(id uint64, wg *sync.WaitGroup) {
+func (c tweetEraseClient) eraseTweet(run *eraseRun, id uint64, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	l := log.WithField("id", id)
+	l := log.WithFields(log.Fields{"run_id": run.ID, "id": id})
 
 	// Create api.
 	api, err := newAPI(c.config)
 	if err != nil {
+		run.countError()
+		c.countFailure()
 		l.Errorf("Fail create api: %s", err)
 		return
 	}
 	defer api.Close()
 
-	t, err := api.DeleteTweet(int64(id), true)
+	// Media urls die with the tweet, so do not erase the tweet which media is not saved.
+	var mediaPaths []string
+	if *backupMediaDir != "" {
+		mediaPaths, err = c.backupMedia(api, id)
+		if err != nil {
+			run.countError()
+			c.countFailure()
+			if writeErr := c.writeEraseError(run.ID, id, err); writeErr != nil {
+				l.Errorf("Fail erase error write: %s", writeErr)
+			}
+
+			l.Errorf("Fail backup media: %s", err)
+			return
+		}
+	}
+
+	t, err := c.deleteTweet(id)
 	if err != nil {
-		insertID, insertErr := c.insertEraseError(id, err)
-		if insertID != 0 && insertErr == nil {
-			l = l.WithField("insert_id", insertID)
-		} else if insertErr != nil {
-			l.Errorf("Fail erase error insert: %s", insertErr)
+		run.countError()
+		c.countFailure()
+		if writeErr := c.writeEraseError(run.ID, id, err); writeErr != nil {
+			l.Errorf("Fail erase error write: %s", writeErr)
 		}
 
 		l.Errorf("Fail erase: %s", err)
 		return
 	}
 
-	insertID, err := c.insertEraseTweet(t)
+	run.countErased()
+	postedAt, err := t.CreatedAtTime()
 	if err != nil {
-		l.Errorf("Fail erase tweet insert: %s", err)
+		l.Errorf("Fail parse posted at: %s", err)
 		return
-	} else if insertID != 0 {
-		postedAt, err := t.CreatedAtTime()
-		if err != nil {
-			l.Errorf("Fail parse posted at: %s", err)
-			return
-		}
+	}
 
-		l = l.WithFields(log.Fields{"insert_id": insertID,
-			"tweet": t.Text, "posted_at": postedAt.Format("2006-01-02 15:04:05")})
+	if err := c.writeEraseTweet(run.ID, t, postedAt, mediaPaths); err != nil {
+		l.Errorf("Fail erase tweet write: %s", err)
+		return
 	}
 
-	l.Info("Successfully erased!")
+	withContent(l, tweetText(t)).WithField("posted_at",
+		postedAt.Format("2006-01-02 15:04:05")).Info("Successfully erased!")
 }
 
-func (c tweetEraseClient) insertEraseTweet(t anaconda.Tweet) (uint64, error) {
-	if c.eraseTweetService == nil {
-		return 0, nil
+// deleteTweet deletes the tweet with tweet_mode=extended,
+// because anaconda DeleteTweet returns the text truncated to 140 characters.
+func (c tweetEraseClient) deleteTweet(id uint64) (anaconda.Tweet, error) {
+	v := url.Values{}
+	v.Set("trim_user", "true")
+	v.Set("tweet_mode", "extended")
+
+	var t anaconda.Tweet
+	if err := newRawAPI(c.config).post(fmt.Sprintf("statuses/destroy/%d.json", id), v, &t); err != nil {
+		return anaconda.Tweet{}, err
 	}
 
-	postedAt, err := t.CreatedAtTime()
-	if err != nil {
-		return 0, err
+	return t, nil
+}
+
+// tweetText returns full_text of the extended mode tweet or text of the compatibility mode tweet.
+func tweetText(t anaconda.Tweet) string {
+	if t.FullText != "" {
+		return t.FullText
+	}
+
+	return t.Text
+}
+
+// withContent adds the text to the log only with --store-content full,
+// so the text is not left in the log when it is not stored.
+func withContent(l *log.Entry, text string) *log.Entry {
+	if *storeContent != model.StoreContentFull {
+		return l
 	}
 
-	et := &model.EraseTweet{TwitterTweetID: uint64(t.Id),
-		Tweet: t.Text, PostedAt: postedAt, TwitterUserID: uint64(t.User.Id)}
-	insertID, err := c.eraseTweetService.Insert(et)
+	return l.WithField("tweet", text)
+}
+
+// writeEraseTweet buffers the erase tweet into the erase result writer.
+func (c tweetEraseClient) writeEraseTweet(runID uint64, t anaconda.Tweet, postedAt time.Time, mediaPaths []string) error {
+	if c.eraseResultWriter == nil {
+		return nil
+	}
+
+	// Keep the complete tweet because the erased content is not recoverable, unless --store-content drops it.
+	rawJSON, err := json.Marshal(t)
 	if err != nil {
-		return 0, err
+		return err
 	}
 
-	return insertID, nil
+	et := &model.EraseTweet{RunID: runID, TwitterTweetID: uint64(t.Id), Tweet: tweetText(t),
+		RawJSON: string(rawJSON), MediaPaths: strings.Join(mediaPaths, ","),
+		PostedAt: postedAt, TwitterUserID: uint64(t.User.Id)}
+	et.StoreContent(*storeContent, c.config.ContentHashSalt)
+	return c.eraseResultWriter.WriteTweet(et)
 }
 
-func (c tweetEraseClient) insertEraseError(tweetID uint64, err error) (uint64, error) {
-	if c.eraseErrorService == nil {
-		return 0, nil
+// writeEraseError buffers the erase error into the erase result writer.
+func (c tweetEraseClient) writeEraseError(runID, tweetID uint64, err error) error {
+	if c.eraseResultWriter == nil {
+		return nil
 	}
 
 	var statusCode uint16
@@ -359,19 +831,67 @@ func (c tweetEraseClient) insertEraseError(tweetID uint64, err error) (uint64, e
 		statusCode = uint16(apiErr.StatusCode)
 	}
 
-	ee := &model.EraseError{TriedTwitterUserID: c.user.UserID, TwitterTweetID: tweetID, StatusCode: statusCode, ErrorMessage: err.Error()}
-	insertID, err := c.eraseErrorService.Insert(ee)
-	if err != nil {
-		return 0, err
+	ee := &model.EraseError{RunID: runID, TriedTwitterUserID: c.user.UserID,
+		TwitterTweetID: tweetID, StatusCode: statusCode, ErrorMessage: err.Error()}
+	return c.eraseResultWriter.WriteError(ee)
+}
+
+// stopOnSignal stops dispatching new erases on SIGINT or SIGTERM.
+// The erases in flight finish and the buffered results are written by close before exit.
+// The returned func stops catching the signals.
+func (c tweetEraseClient) stopOnSignal() func() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-sigCh:
+			log.WithField("signal", sig).Warn("Stopping after the erases in flight. Signal again to quit immediately.")
+			close(c.stop)
+			signal.Stop(sigCh)
+		case <-done:
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigCh)
+		close(done)
 	}
+}
 
-	return insertID, nil
+// stopped reports whether the client is stopped by the signal.
+func (c tweetEraseClient) stopped() bool {
+	select {
+	case <-c.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// countFailure counts the failed erase of the erase goroutines.
+func (c tweetEraseClient) countFailure() {
+	atomic.AddUint64(c.failures, 1)
+}
+
+// failureCount returns the number of the failed erases counted by countFailure.
+func (c tweetEraseClient) failureCount() uint64 {
+	return atomic.LoadUint64(c.failures)
 }
 
 func (c tweetEraseClient) close() error {
 	c.api.Close()
 
-	if err := c.db.Close(); err != nil {
+	if c.eraseResultWriter != nil {
+		if err := c.eraseResultWriter.Close(); err != nil {
+			log.Errorf("Fail erase result write: %s", err)
+		}
+	}
+
+	if c.db == nil {
+		return nil
+	} else if err := c.db.Close(); err != nil {
 		return err
 	}
 
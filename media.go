@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+	"github.com/pkg/errors"
+)
+
+// mediaDownloadTimeout is the time limit of downloading one media file including the video body.
+const mediaDownloadTimeout = 5 * time.Minute
+
+// mediaHTTPClient downloads the media, so a stalled download does not block the erase forever.
+var mediaHTTPClient = &http.Client{Timeout: mediaDownloadTimeout}
+
+// mediaURLs returns the urls of the photos and the highest bitrate videos and gifs of the tweet.
+func mediaURLs(t anaconda.Tweet) []string {
+	var urls []string
+	for _, m := range t.ExtendedEntities.Media {
+		if m.Type == "photo" {
+			urls = append(urls, m.Media_url_https+":orig")
+			continue
+		}
+
+		var variant *anaconda.Variant
+		for i, v := range m.VideoInfo.Variants {
+			if v.ContentType != "video/mp4" {
+				continue
+			} else if variant == nil || v.Bitrate > variant.Bitrate {
+				variant = &m.VideoInfo.Variants[i]
+			}
+		}
+
+		if variant != nil {
+			urls = append(urls, variant.Url)
+		}
+	}
+
+	return urls
+}
+
+// mediaStore is content-addressed local store of media files.
+// A file is saved as <dir>/<first 2 chars of sha256>/<sha256><ext>,
+// so the same media is saved only once.
+type mediaStore struct {
+	dir string
+}
+
+// save downloads the media and returns the saved file path.
+func (s mediaStore) save(mediaURL string) (string, error) {
+	u, err := url.Parse(mediaURL)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := mediaHTTPClient.Get(mediaURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", errors.Errorf("Fail download %s: %s.", mediaURL, resp.Status)
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return "", err
+	}
+
+	f, err := ioutil.TempFile(s.dir, ".download")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), resp.Body); err != nil {
+		f.Close()
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	// Strip the size suffix of the photo url (e.g. .jpg:orig).
+	ext := path.Ext(u.Path)
+	if i := len(ext) - len(":orig"); i > 0 && ext[i:] == ":orig" {
+		ext = ext[:i]
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	p := filepath.Join(s.dir, sum[:2], sum+ext)
+	if _, err := os.Stat(p); err == nil {
+		return p, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(f.Name(), p); err != nil {
+		return "", err
+	}
+
+	return p, nil
+}
+
+// backupMedia saves all media of the tweet and returns the saved file paths.
+func (c tweetEraseClient) backupMedia(api *anaconda.TwitterApi, id uint64) ([]string, error) {
+	v := url.Values{}
+	v.Set("tweet_mode", "extended")
+	t, err := api.GetTweet(int64(id), v)
+	if err != nil {
+		return nil, err
+	}
+
+	s := mediaStore{dir: *backupMediaDir}
+	var paths []string
+	for _, u := range mediaURLs(t) {
+		p, err := s.save(u)
+		if err != nil {
+			return nil, err
+		}
+
+		paths = append(paths, p)
+	}
+
+	return paths, nil
+}
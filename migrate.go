@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/178inaba/tweeraser/config"
+	"github.com/178inaba/tweeraser/model"
+	log "github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+)
+
+// migrate runs the migrate sub command without twitter api.
+func migrate(cmd string) error {
+	conf, err := config.LoadConfig(configFilePath)
+	if err != nil {
+		return err
+	}
+
+	db, err := newDB(conf.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	s := newSchemaMigrationService(conf.Database.Driver, db)
+	switch cmd {
+	case migrateUpCmd.FullCommand():
+		sms, err := s.Up()
+		for _, sm := range sms {
+			log.WithFields(log.Fields{"version": sm.Version, "name": sm.Name}).Info("Successfully migrated up!")
+		}
+
+		if err != nil {
+			return err
+		} else if len(sms) == 0 {
+			log.WithField("version", s.LatestVersion()).Info("Schema is up to date.")
+		}
+	case migrateDownCmd.FullCommand():
+		sm, err := s.Down()
+		if err != nil {
+			return err
+		} else if sm == nil {
+			log.Info("No migration to roll back.")
+			return nil
+		}
+
+		log.WithFields(log.Fields{"version": sm.Version, "name": sm.Name}).Info("Successfully migrated down!")
+	case migrateStatusCmd.FullCommand():
+		sms, err := s.Statuses()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, sm := range sms {
+			appliedAt := "pending"
+			if sm.Applied() {
+				appliedAt = sm.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+
+			fmt.Fprintf(w, "%d\t%s\t%s\n", sm.Version, sm.Name, appliedAt)
+		}
+
+		return w.Flush()
+	}
+
+	return nil
+}
+
+// checkSchemaVersion refuses the database whose schema is older than this program expects.
+func checkSchemaVersion(s model.SchemaMigrationService) error {
+	version, err := s.Version()
+	if err != nil {
+		return err
+	}
+
+	latest := s.LatestVersion()
+	if version < latest {
+		return errors.Errorf("Database schema version %d is older than %d. Run `tweeraser migrate up`.", version, latest)
+	} else if version > latest {
+		log.WithFields(log.Fields{"version": version, "latest": latest}).
+			Warn("Database schema is newer than this program expects.")
+	}
+
+	return nil
+}
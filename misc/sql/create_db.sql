@@ -1,2 +1,2 @@
 DROP DATABASE IF EXISTS tweeraser;
-CREATE DATABASE tweeraser CHARACTER SET utf8;
+CREATE DATABASE tweeraser CHARACTER SET utf8mb4;
@@ -1,2 +1,2 @@
 DROP DATABASE IF EXISTS tweeraser_test;
-CREATE DATABASE tweeraser_test CHARACTER SET utf8;
+CREATE DATABASE tweeraser_test CHARACTER SET utf8mb4;
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// ArchivedTweetTableName is archived tweet table name.
+const ArchivedTweetTableName = "archived_tweets"
+
+// ArchivedTweet is archived tweet object.
+// Zero value ids mean that the tweet has no corresponding reply or retweet.
+type ArchivedTweet struct {
+	ID                    uint64
+	TwitterUserID         uint64
+	TwitterTweetID        uint64
+	InReplyToStatusID     uint64
+	InReplyToUserID       uint64
+	RetweetedStatusID     uint64
+	RetweetedStatusUserID uint64
+	Source                string
+	Tweet                 string
+	ExpandedURLs          string
+	PostedAt              time.Time
+	UpdatedAt             time.Time
+	CreatedAt             time.Time
+}
+
+// ArchivedTweetService is archived tweet service interface.
+type ArchivedTweetService interface {
+	BulkInsertUpdate(ats []*ArchivedTweet) error
+	InteractedUserIDs(userID uint64) ([]uint64, error)
+	TweetIDs(userID uint64) ([]uint64, error)
+	ScrubErasedContent(userID uint64) (uint64, error)
+}
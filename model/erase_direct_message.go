@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// EraseDirectMessageTableName is erase direct message table name.
+const EraseDirectMessageTableName = "erase_direct_messages"
+
+// EraseDirectMessage is erase direct message object.
+type EraseDirectMessage struct {
+	ID                     uint64
+	TwitterDirectMessageID uint64
+	ConversationID         string
+	SenderID               uint64
+	RecipientID            uint64
+	Message                string
+	SentAt                 time.Time
+	TwitterUserID          uint64
+	UpdatedAt              time.Time
+	CreatedAt              time.Time
+}
+
+// EraseDirectMessageService is erase direct message service interface.
+type EraseDirectMessageService interface {
+	AlreadyEraseDirectMessageIDs(userID uint64, ids []uint64) ([]uint64, error)
+	Insert(edm *EraseDirectMessage) (uint64, error)
+	ScrubContent(userID uint64) (uint64, error)
+}
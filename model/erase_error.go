@@ -6,18 +6,83 @@ import "time"
 const EraseErrorTableName = "erase_errors"
 
 // EraseError is erace error object.
+// One row is kept per tried user and tweet. RunID, StatusCode and ErrorMessage are of the last error.
+// RunID is 0 if the error is out of a run.
 type EraseError struct {
 	ID                 uint64
+	RunID              uint64
 	TriedTwitterUserID uint64
 	TwitterTweetID     uint64
+	Attempts           uint32
 	StatusCode         uint16
 	ErrorMessage       string
+	FirstErrorAt       time.Time
+	LastErrorAt        time.Time
 	UpdatedAt          time.Time
 	CreatedAt          time.Time
 }
 
 // EraseErrorService is erase error service interface.
+// Insert and BulkInsert upsert the row of the same tried user and tweet, and count up its attempts.
+// TweetStatusCodes returns the status code by the tweet id and RetryTweetIDs returns the tweet ids of the errors except not found.
 type EraseErrorService interface {
 	TweetNotFoundIDs(userID uint64, ids []uint64) ([]uint64, error)
 	Insert(ee *EraseError) (uint64, error)
+	BulkInsert(ees []*EraseError) error
+	TweetStatusCodes(userID uint64) (map[uint64]uint16, error)
+	RetryTweetIDs(userID uint64) ([]uint64, error)
+}
+
+// ErrorTimes returns the first and the last error time to store.
+// The zero last error time is now, and the zero first error time is the last error time.
+func (ee *EraseError) ErrorTimes(now time.Time) (first, last time.Time) {
+	first, last = ee.FirstErrorAt, ee.LastErrorAt
+	if last.IsZero() {
+		last = now
+	}
+
+	if first.IsZero() {
+		first = last
+	}
+
+	return first, last
+}
+
+// CollapseEraseErrors merges the erase errors of the same tried user and tweet in order.
+// Attempts of the returned error is the sum of the attempts of the merged errors, the zero attempts is counted as one,
+// and the run id, status code, error message and last error time are of the last one.
+func CollapseEraseErrors(ees []*EraseError) []*EraseError {
+	type key struct{ userID, tweetID uint64 }
+	indexes := map[key]int{}
+	var collapsed []*EraseError
+	for _, ee := range ees {
+		k := key{ee.TriedTwitterUserID, ee.TwitterTweetID}
+		i, ok := indexes[k]
+		if !ok {
+			c := *ee
+			c.Attempts = attempts(ee)
+			indexes[k] = len(collapsed)
+			collapsed = append(collapsed, &c)
+			continue
+		}
+
+		collapsed[i].Attempts += attempts(ee)
+		collapsed[i].RunID = ee.RunID
+		collapsed[i].StatusCode = ee.StatusCode
+		collapsed[i].ErrorMessage = ee.ErrorMessage
+		if !ee.LastErrorAt.IsZero() {
+			collapsed[i].LastErrorAt = ee.LastErrorAt
+		}
+	}
+
+	return collapsed
+}
+
+// attempts returns the attempts of the erase error. The new error has no attempts and it is one attempt.
+func attempts(ee *EraseError) uint32 {
+	if ee.Attempts == 0 {
+		return 1
+	}
+
+	return ee.Attempts
 }
@@ -0,0 +1,57 @@
+package model_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollapseEraseErrors(t *testing.T) {
+	ees := []*model.EraseError{
+		{TriedTwitterUserID: 1, TwitterTweetID: 1, StatusCode: http.StatusInternalServerError},
+		{TriedTwitterUserID: 1, TwitterTweetID: 2, StatusCode: http.StatusNotFound},
+		{TriedTwitterUserID: 2, TwitterTweetID: 1, StatusCode: http.StatusNotFound},
+		{TriedTwitterUserID: 1, TwitterTweetID: 1, StatusCode: http.StatusNotFound, ErrorMessage: "Error: status 404."},
+	}
+
+	collapsed := model.CollapseEraseErrors(ees)
+	assert.Len(t, collapsed, 3)
+	assert.Equal(t, uint32(2), collapsed[0].Attempts)
+	assert.Equal(t, uint16(http.StatusNotFound), collapsed[0].StatusCode)
+	assert.Equal(t, "Error: status 404.", collapsed[0].ErrorMessage)
+	assert.Equal(t, uint32(1), collapsed[1].Attempts)
+	assert.Equal(t, uint64(2), collapsed[2].TriedTwitterUserID)
+
+	// The attempts of the stored error are summed.
+	collapsed = model.CollapseEraseErrors([]*model.EraseError{
+		{TriedTwitterUserID: 1, TwitterTweetID: 1, Attempts: 3},
+		{TriedTwitterUserID: 1, TwitterTweetID: 1},
+	})
+	assert.Len(t, collapsed, 1)
+	assert.Equal(t, uint32(4), collapsed[0].Attempts)
+
+	// The argument is not changed.
+	assert.Equal(t, uint32(0), ees[0].Attempts)
+	assert.Equal(t, uint16(http.StatusInternalServerError), ees[0].StatusCode)
+}
+
+func TestErrorTimes(t *testing.T) {
+	now := time.Now().UTC()
+	first, last := (&model.EraseError{}).ErrorTimes(now)
+	assert.Equal(t, now, first)
+	assert.Equal(t, now, last)
+
+	firstErrorAt := now.Add(-2 * time.Hour)
+	lastErrorAt := now.Add(-time.Hour)
+	first, last = (&model.EraseError{FirstErrorAt: firstErrorAt, LastErrorAt: lastErrorAt}).ErrorTimes(now)
+	assert.Equal(t, firstErrorAt, first)
+	assert.Equal(t, lastErrorAt, last)
+
+	// The first error time is not after the last error time.
+	first, last = (&model.EraseError{LastErrorAt: lastErrorAt}).ErrorTimes(now)
+	assert.Equal(t, lastErrorAt, first)
+	assert.Equal(t, lastErrorAt, last)
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// EraseLikeTableName is erase like table name.
+const EraseLikeTableName = "erase_likes"
+
+// EraseLike is erase like object.
+type EraseLike struct {
+	ID                 uint64
+	TwitterTweetID     uint64
+	Tweet              string
+	TweetTwitterUserID uint64
+	TwitterUserID      uint64
+	UpdatedAt          time.Time
+	CreatedAt          time.Time
+}
+
+// EraseLikeService is erase like service interface.
+type EraseLikeService interface {
+	AlreadyEraseLikeIDs(userID uint64, ids []uint64) ([]uint64, error)
+	Insert(el *EraseLike) (uint64, error)
+	ScrubContent(userID uint64) (uint64, error)
+}
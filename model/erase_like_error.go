@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// EraseLikeErrorTableName is erase like error table name.
+const EraseLikeErrorTableName = "erase_like_errors"
+
+// EraseLikeError is erase like error object.
+type EraseLikeError struct {
+	ID                 uint64
+	TriedTwitterUserID uint64
+	TwitterTweetID     uint64
+	StatusCode         uint16
+	ErrorMessage       string
+	UpdatedAt          time.Time
+	CreatedAt          time.Time
+}
+
+// EraseLikeErrorService is erase like error service interface.
+type EraseLikeErrorService interface {
+	LikeNotFoundIDs(userID uint64, ids []uint64) ([]uint64, error)
+	Insert(ele *EraseLikeError) (uint64, error)
+}
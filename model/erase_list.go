@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// EraseListTableName is erase list table name.
+const EraseListTableName = "erase_lists"
+
+// EraseList is erase list object.
+type EraseList struct {
+	ID            uint64
+	TwitterListID uint64
+	Name          string
+	Mode          string
+	Description   string
+	MemberCount   uint64
+	TwitterUserID uint64
+	UpdatedAt     time.Time
+	CreatedAt     time.Time
+}
+
+// EraseListService is erase list service interface.
+type EraseListService interface {
+	Insert(el *EraseList) (uint64, error)
+}
@@ -0,0 +1,151 @@
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+// EraseResultWriter buffers erase tweets and errors and writes them by bulk insert.
+// The buffers are flushed when either reaches the size, every interval and on Close.
+// The rows of the failed bulk insert are kept in the buffers and retried by the next flush,
+// because the content of the erased tweets is not recoverable.
+type EraseResultWriter struct {
+	ets      EraseTweetService
+	ees      EraseErrorService
+	size     int
+	onError  func(err error)
+	fallback func() (EraseTweetService, EraseErrorService, error)
+
+	mu     sync.Mutex
+	tweets []*EraseTweet
+	errors []*EraseError
+
+	// flushMu serializes the bulk inserts.
+	flushMu sync.Mutex
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewEraseResultWriter creates the writer and starts flushing every interval.
+// onError is called with the error of the flush by interval.
+func NewEraseResultWriter(ets EraseTweetService, ees EraseErrorService,
+	size int, interval time.Duration, onError func(err error)) *EraseResultWriter {
+	w := &EraseResultWriter{ets: ets, ees: ees, size: size, onError: onError, done: make(chan struct{})}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := w.Flush(); err != nil {
+					w.onError(err)
+				}
+			case <-w.done:
+				return
+			}
+		}
+	}()
+
+	return w
+}
+
+// SetFallback sets the services which the rows still failed on Close are written to (e.g. the journal).
+// The services are created by fallback only when they are needed.
+func (w *EraseResultWriter) SetFallback(fallback func() (EraseTweetService, EraseErrorService, error)) {
+	w.fallback = fallback
+}
+
+// WriteTweet buffers the erase tweet. It flushes the buffers when the size is reached.
+func (w *EraseResultWriter) WriteTweet(et *EraseTweet) error {
+	w.mu.Lock()
+	w.tweets = append(w.tweets, et)
+	full := len(w.tweets) >= w.size
+	w.mu.Unlock()
+
+	if full {
+		return w.Flush()
+	}
+
+	return nil
+}
+
+// WriteError buffers the erase error. It flushes the buffers when the size is reached.
+func (w *EraseResultWriter) WriteError(ee *EraseError) error {
+	w.mu.Lock()
+	w.errors = append(w.errors, ee)
+	full := len(w.errors) >= w.size
+	w.mu.Unlock()
+
+	if full {
+		return w.Flush()
+	}
+
+	return nil
+}
+
+// Flush writes all buffered erase tweets and errors.
+// The rows of the failed bulk insert are put back in front of the buffers to retry.
+func (w *EraseResultWriter) Flush() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	return w.flush(w.ets, w.ees)
+}
+
+func (w *EraseResultWriter) flush(ets EraseTweetService, ees EraseErrorService) error {
+	w.mu.Lock()
+	tweets, errs := w.tweets, w.errors
+	w.tweets, w.errors = nil, nil
+	w.mu.Unlock()
+
+	var err error
+	if len(tweets) > 0 {
+		if err = ets.BulkInsert(tweets); err != nil {
+			w.mu.Lock()
+			w.tweets = append(tweets, w.tweets...)
+			w.mu.Unlock()
+		}
+	}
+
+	if len(errs) > 0 {
+		if eesErr := ees.BulkInsert(errs); eesErr != nil {
+			w.mu.Lock()
+			w.errors = append(errs, w.errors...)
+			w.mu.Unlock()
+
+			if err == nil {
+				err = eesErr
+			}
+		}
+	}
+
+	return err
+}
+
+// Close stops flushing by interval and flushes the rest.
+// If the rest still fails, it is written to the fallback services.
+func (w *EraseResultWriter) Close() error {
+	close(w.done)
+	w.wg.Wait()
+
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	err := w.flush(w.ets, w.ees)
+	if err == nil || w.fallback == nil {
+		return err
+	}
+
+	w.onError(err)
+	ets, ees, err := w.fallback()
+	if err != nil {
+		return err
+	}
+
+	return w.flush(ets, ees)
+}
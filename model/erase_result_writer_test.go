@@ -0,0 +1,123 @@
+package model_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeEraseTweetService struct {
+	model.EraseTweetService
+
+	mu      sync.Mutex
+	batches [][]*model.EraseTweet
+	err     error
+}
+
+func (s *fakeEraseTweetService) BulkInsert(ets []*model.EraseTweet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batches = append(s.batches, ets)
+	return s.err
+}
+
+func (s *fakeEraseTweetService) batchCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.batches)
+}
+
+type fakeEraseErrorService struct {
+	model.EraseErrorService
+
+	batches [][]*model.EraseError
+}
+
+func (s *fakeEraseErrorService) BulkInsert(ees []*model.EraseError) error {
+	s.batches = append(s.batches, ees)
+	return nil
+}
+
+func TestEraseResultWriterFlushBySize(t *testing.T) {
+	ets := &fakeEraseTweetService{}
+	ees := &fakeEraseErrorService{}
+	w := model.NewEraseResultWriter(ets, ees, 2, time.Hour, func(err error) { t.Error(err) })
+
+	assert.NoError(t, w.WriteTweet(&model.EraseTweet{TwitterTweetID: 1}))
+	assert.Equal(t, 0, ets.batchCount())
+
+	assert.NoError(t, w.WriteTweet(&model.EraseTweet{TwitterTweetID: 2}))
+	assert.Equal(t, 1, ets.batchCount())
+	assert.Len(t, ets.batches[0], 2)
+
+	// Rest is flushed on close.
+	assert.NoError(t, w.WriteTweet(&model.EraseTweet{TwitterTweetID: 3}))
+	assert.NoError(t, w.WriteError(&model.EraseError{TwitterTweetID: 4}))
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 2, ets.batchCount())
+	assert.Len(t, ets.batches[1], 1)
+	assert.Len(t, ees.batches, 1)
+	assert.Equal(t, uint64(4), ees.batches[0][0].TwitterTweetID)
+}
+
+func TestEraseResultWriterFlushByInterval(t *testing.T) {
+	ets := &fakeEraseTweetService{}
+	w := model.NewEraseResultWriter(ets, &fakeEraseErrorService{}, 100, 10*time.Millisecond,
+		func(err error) { t.Error(err) })
+	defer w.Close()
+
+	assert.NoError(t, w.WriteTweet(&model.EraseTweet{TwitterTweetID: 1}))
+	for i := 0; i < 100 && ets.batchCount() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.Equal(t, 1, ets.batchCount())
+}
+
+func TestEraseResultWriterError(t *testing.T) {
+	ets := &fakeEraseTweetService{err: errors.New("bulk insert error")}
+	w := model.NewEraseResultWriter(ets, &fakeEraseErrorService{}, 1, time.Hour, func(err error) {})
+
+	assert.Error(t, w.WriteTweet(&model.EraseTweet{TwitterTweetID: 1}))
+
+	// The failed rows are retried with the following rows.
+	ets.mu.Lock()
+	ets.err = nil
+	ets.mu.Unlock()
+
+	assert.NoError(t, w.WriteTweet(&model.EraseTweet{TwitterTweetID: 2}))
+	assert.Equal(t, 2, ets.batchCount())
+	assert.Len(t, ets.batches[1], 2)
+	assert.Equal(t, uint64(1), ets.batches[1][0].TwitterTweetID)
+
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 2, ets.batchCount())
+}
+
+func TestEraseResultWriterFallback(t *testing.T) {
+	ets := &fakeEraseTweetService{err: errors.New("bulk insert error")}
+	var onErrorCnt int
+	w := model.NewEraseResultWriter(ets, &fakeEraseErrorService{}, 100, time.Hour, func(err error) { onErrorCnt++ })
+
+	fallbackETS := &fakeEraseTweetService{}
+	fallbackEES := &fakeEraseErrorService{}
+	w.SetFallback(func() (model.EraseTweetService, model.EraseErrorService, error) {
+		return fallbackETS, fallbackEES, nil
+	})
+
+	assert.NoError(t, w.WriteTweet(&model.EraseTweet{TwitterTweetID: 1}))
+	assert.NoError(t, w.WriteError(&model.EraseError{TwitterTweetID: 2}))
+
+	// The rows still failed on close are written to the fallback.
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 1, onErrorCnt)
+	assert.Equal(t, 1, fallbackETS.batchCount())
+	assert.Equal(t, uint64(1), fallbackETS.batches[0][0].TwitterTweetID)
+	assert.Len(t, fallbackEES.batches, 0)
+}
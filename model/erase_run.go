@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// EraseRunTableName is erase run table name.
+const EraseRunTableName = "erase_runs"
+
+// EraseRun is one run erasing tweets. Erase tweets and errors refer to the run by RunID.
+// Sources is comma separated sources of the tweet ids (csv, zip, timeline, search or query).
+// Filters and Options are json objects of the flags. EndedAt is nil while running or if the run is aborted.
+type EraseRun struct {
+	ID            uint64
+	TwitterUserID uint64
+	Command       string
+	Sources       string
+	Filters       string
+	Options       string
+	Host          string
+	ToolVersion   string
+	TargetCount   uint64
+	ErasedCount   uint64
+	ErrorCount    uint64
+	StartedAt     time.Time
+	EndedAt       *time.Time
+	UpdatedAt     time.Time
+	CreatedAt     time.Time
+}
+
+// EraseRunService is erase run service interface.
+type EraseRunService interface {
+	Insert(er *EraseRun) (uint64, error)
+	Update(er *EraseRun) error
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// EraseSavedSearchTableName is erase saved search table name.
+const EraseSavedSearchTableName = "erase_saved_searches"
+
+// EraseSavedSearch is erase saved search object.
+type EraseSavedSearch struct {
+	ID                   uint64
+	TwitterSavedSearchID uint64
+	Query                string
+	TwitterUserID        uint64
+	UpdatedAt            time.Time
+	CreatedAt            time.Time
+}
+
+// EraseSavedSearchService is erase saved search service interface.
+type EraseSavedSearchService interface {
+	Insert(ess *EraseSavedSearch) (uint64, error)
+}
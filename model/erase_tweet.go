@@ -1,15 +1,35 @@
 package model
 
-import "time"
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
 
 // EraseTweetTableName is erase tweet table name.
 const EraseTweetTableName = "erase_tweets"
 
+// Store content modes of the erase tweet.
+// None keeps only the ids and the dates, hash keeps the salted hash of the tweet also and full keeps all.
+const (
+	StoreContentNone = "none"
+	StoreContentHash = "hash"
+	StoreContentFull = "full"
+)
+
 // EraseTweet is erace tweet object.
+// RunID is the id of the erase run, 0 if the tweet is erased out of a run.
+// TweetHash is the salted hash of the tweet kept instead of the tweet by the hash store content mode.
+// MediaPaths is comma separated file paths of the backed up media.
 type EraseTweet struct {
 	ID             uint64
+	RunID          uint64
 	TwitterTweetID uint64
 	Tweet          string
+	TweetHash      string
+	RawJSON        string
+	MediaPaths     string
 	PostedAt       time.Time
 	TwitterUserID  uint64
 	UpdatedAt      time.Time
@@ -17,7 +37,61 @@ type EraseTweet struct {
 }
 
 // EraseTweetService is service interface.
+// Insert and BulkInsert update the row of the same user and tweet if it exists.
+// CountByPostedMonth counts by the posted month of "2006-01" and CountBySource counts by the source html of the raw json.
+// EraseTweets returns the erased tweets of the user in order of the posted time.
+// ScrubContent updates the content of the erase tweets. Whether the former content remains in the storage depends on the store.
 type EraseTweetService interface {
 	AlreadyEraseTweetIDs(userID uint64, ids []uint64) ([]uint64, error)
 	Insert(et *EraseTweet) (uint64, error)
+	BulkInsert(ets []*EraseTweet) error
+	CountByPostedMonth(userID uint64) ([]*KeyCount, error)
+	CountBySource(userID uint64) ([]*KeyCount, error)
+	EraseTweets(userID uint64) ([]*EraseTweet, error)
+	ScrubContent(ets []*EraseTweet) error
+}
+
+// UniqueEraseTweets returns the erase tweets without the duplicates of the same user and tweet.
+// The last one wins like the upsert.
+func UniqueEraseTweets(ets []*EraseTweet) []*EraseTweet {
+	type key struct{ userID, tweetID uint64 }
+	indexes := map[key]int{}
+	var unique []*EraseTweet
+	for _, et := range ets {
+		k := key{et.TwitterUserID, et.TwitterTweetID}
+		if i, ok := indexes[k]; ok {
+			unique[i] = et
+			continue
+		}
+
+		indexes[k] = len(unique)
+		unique = append(unique, et)
+	}
+
+	return unique
+}
+
+// StoreContent drops the content of the erase tweet which is not kept by the store content mode.
+// The media paths are dropped with the text, because the backed up media is the content also.
+// The hash of the already dropped tweet is kept.
+func (et *EraseTweet) StoreContent(mode, salt string) {
+	switch mode {
+	case StoreContentFull:
+		return
+	case StoreContentHash:
+		if et.Tweet != "" {
+			et.TweetHash = TweetHash(salt, et.Tweet)
+		}
+	default:
+		et.TweetHash = ""
+	}
+
+	et.Tweet, et.RawJSON, et.MediaPaths = "", "", ""
+}
+
+// TweetHash returns the hex of HMAC-SHA256 of the tweet with the salt as the key.
+func TweetHash(salt, tweet string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(tweet))
+	return hex.EncodeToString(mac.Sum(nil))
 }
@@ -0,0 +1,54 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUniqueEraseTweets(t *testing.T) {
+	ets := []*model.EraseTweet{
+		{TwitterUserID: 1, TwitterTweetID: 1, Tweet: "first"},
+		{TwitterUserID: 1, TwitterTweetID: 2},
+		{TwitterUserID: 2, TwitterTweetID: 1},
+		{TwitterUserID: 1, TwitterTweetID: 1, Tweet: "last"},
+	}
+
+	unique := model.UniqueEraseTweets(ets)
+	assert.Len(t, unique, 3)
+	assert.Equal(t, "last", unique[0].Tweet)
+	assert.Equal(t, uint64(2), unique[1].TwitterTweetID)
+	assert.Equal(t, uint64(2), unique[2].TwitterUserID)
+}
+
+func TestStoreContent(t *testing.T) {
+	newEraseTweet := func() *model.EraseTweet {
+		return &model.EraseTweet{TwitterTweetID: 1, Tweet: "tweet", RawJSON: `{"id":1}`, MediaPaths: "media/ab/ab.jpg"}
+	}
+
+	et := newEraseTweet()
+	et.StoreContent(model.StoreContentFull, "salt")
+	assert.Equal(t, newEraseTweet(), et)
+
+	et = newEraseTweet()
+	et.StoreContent(model.StoreContentHash, "salt")
+	assert.Equal(t, "", et.Tweet)
+	assert.Equal(t, "", et.RawJSON)
+	assert.Equal(t, "", et.MediaPaths)
+	assert.Equal(t, model.TweetHash("salt", "tweet"), et.TweetHash)
+	assert.Len(t, et.TweetHash, 64)
+	assert.NotEqual(t, model.TweetHash("other salt", "tweet"), et.TweetHash)
+
+	// The hash of the dropped tweet is kept.
+	et.StoreContent(model.StoreContentHash, "salt")
+	assert.Equal(t, model.TweetHash("salt", "tweet"), et.TweetHash)
+
+	et.StoreContent(model.StoreContentNone, "salt")
+	assert.Equal(t, &model.EraseTweet{TwitterTweetID: 1}, et)
+
+	// The backed up media is not kept with none.
+	et = newEraseTweet()
+	et.StoreContent(model.StoreContentNone, "salt")
+	assert.Equal(t, &model.EraseTweet{TwitterTweetID: 1}, et)
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+const (
+	// FollowingSnapshotTableName is following snapshot table name.
+	FollowingSnapshotTableName = "following_snapshots"
+
+	// FollowingTableName is following table name.
+	FollowingTableName = "followings"
+)
+
+// FollowingSnapshot is snapshot object of the user followings.
+type FollowingSnapshot struct {
+	ID            uint64
+	TwitterUserID uint64
+	UpdatedAt     time.Time
+	CreatedAt     time.Time
+}
+
+// Following is following user object of the snapshot.
+type Following struct {
+	ID                     uint64
+	FollowingSnapshotID    uint64
+	FollowingTwitterUserID uint64
+	Unfollowed             bool
+	UpdatedAt              time.Time
+	CreatedAt              time.Time
+}
+
+// FollowingSnapshotService is following snapshot service interface.
+type FollowingSnapshotService interface {
+	Insert(fs *FollowingSnapshot, followingUserIDs []uint64) (uint64, error)
+	Followings(userID, snapshotID uint64) ([]*Following, error)
+	UpdateUnfollowed(snapshotID, followingUserID uint64) error
+}
@@ -0,0 +1,160 @@
+package journal
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+)
+
+// eraseErrorKey is the unique key of the erase error.
+type eraseErrorKey struct {
+	triedUserID uint64
+	tweetID     uint64
+}
+
+// EraseErrorService is erase errors journal service.
+// The last line of each tweet is the current error, so the attempts are carried over to it.
+type EraseErrorService struct {
+	mu   sync.RWMutex
+	j    *journal
+	errs map[eraseErrorKey]model.EraseError
+}
+
+// NewEraseErrorService is create erase error service of the journal directory.
+func NewEraseErrorService(dir string) (*EraseErrorService, error) {
+	errs := map[eraseErrorKey]model.EraseError{}
+	j, err := openJournal(dir, model.EraseErrorTableName, func(line []byte) error {
+		var ee model.EraseError
+		if err := json.Unmarshal(line, &ee); err != nil {
+			return err
+		}
+
+		errs[eraseErrorKey{ee.TriedTwitterUserID, ee.TwitterTweetID}] = ee
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &EraseErrorService{j: j, errs: errs}, nil
+}
+
+// TweetNotFoundIDs return not found tweet ids from argument ids.
+func (s *EraseErrorService) TweetNotFoundIDs(userID uint64, ids []uint64) ([]uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tweetIDs []uint64
+	for _, id := range ids {
+		if ee, ok := s.errs[eraseErrorKey{userID, id}]; ok && ee.StatusCode == http.StatusNotFound {
+			tweetIDs = append(tweetIDs, id)
+		}
+	}
+
+	return tweetIDs, nil
+}
+
+// Insert appends the erase error to the journal.
+// If the error of the same tweet exists, it counts up the attempts and returns the id of it.
+func (s *EraseErrorService) Insert(ee *model.EraseError) (uint64, error) {
+	if err := s.BulkInsert([]*model.EraseError{ee}); err != nil {
+		return 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.errs[eraseErrorKey{ee.TriedTwitterUserID, ee.TwitterTweetID}].ID, nil
+}
+
+// BulkInsert appends the erase errors to the journal by one write.
+func (s *EraseErrorService) BulkInsert(ees []*model.EraseError) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ees = model.CollapseEraseErrors(ees)
+	now := time.Now().UTC()
+	records := make([]model.EraseError, len(ees))
+	for i, ee := range ees {
+		records[i] = *ee
+		records[i].FirstErrorAt, records[i].LastErrorAt = ee.ErrorTimes(now)
+		records[i].UpdatedAt, records[i].CreatedAt = now, now
+		if old, ok := s.errs[eraseErrorKey{ee.TriedTwitterUserID, ee.TwitterTweetID}]; ok {
+			records[i].ID, records[i].Attempts = old.ID, old.Attempts+ee.Attempts
+			records[i].FirstErrorAt, records[i].CreatedAt = old.FirstErrorAt, old.CreatedAt
+		}
+	}
+
+	_, err := s.j.appendAll(len(records), func(i int) interface{} { return &records[i] }, func(i int, id uint64) {
+		if records[i].ID == 0 {
+			records[i].ID = id
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		s.errs[eraseErrorKey{r.TriedTwitterUserID, r.TwitterTweetID}] = r
+	}
+
+	return nil
+}
+
+// EraseErrors returns the current erase errors of the tried user in order of the tweet id.
+func (s *EraseErrorService) EraseErrors(userID uint64) ([]*model.EraseError, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ees byTweetID
+	for k, ee := range s.errs {
+		if k.triedUserID == userID {
+			record := ee
+			ees = append(ees, &record)
+		}
+	}
+
+	sort.Sort(ees)
+	return ees, nil
+}
+
+// byTweetID sorts the erase errors by the tweet id.
+type byTweetID []*model.EraseError
+
+func (a byTweetID) Len() int           { return len(a) }
+func (a byTweetID) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byTweetID) Less(i, j int) bool { return a[i].TwitterTweetID < a[j].TwitterTweetID }
+
+// TweetStatusCodes returns the status code of the error by the tweet id of the tried user.
+func (s *EraseErrorService) TweetStatusCodes(userID uint64) (map[uint64]uint16, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statusCodes := map[uint64]uint16{}
+	for k, ee := range s.errs {
+		if k.triedUserID == userID {
+			statusCodes[k.tweetID] = ee.StatusCode
+		}
+	}
+
+	return statusCodes, nil
+}
+
+// RetryTweetIDs returns the tweet ids of the errors except not found, that can be erased by retry.
+func (s *EraseErrorService) RetryTweetIDs(userID uint64) ([]uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tweetIDs []uint64
+	for k, ee := range s.errs {
+		if k.triedUserID == userID && ee.StatusCode != http.StatusNotFound {
+			tweetIDs = append(tweetIDs, k.tweetID)
+		}
+	}
+
+	return tweetIDs, nil
+}
@@ -0,0 +1,165 @@
+package journal_test
+
+import (
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/journal"
+	"github.com/stretchr/testify/suite"
+)
+
+type eraseErrorSuite struct {
+	suite.Suite
+
+	dir     string
+	service model.EraseErrorService
+}
+
+func TestEraseErrorSuite(t *testing.T) {
+	suite.Run(t, new(eraseErrorSuite))
+}
+
+func (s *eraseErrorSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "tweeraser_journal")
+	s.Require().NoError(err)
+
+	s.dir = dir
+	s.service, err = journal.NewEraseErrorService(dir)
+	s.Require().NoError(err)
+}
+
+func (s *eraseErrorSuite) TestTweetNotFoundIDs() {
+	userID := uint64(1)
+	ees := []*model.EraseError{
+		{TriedTwitterUserID: userID, TwitterTweetID: 1, StatusCode: http.StatusNotFound},
+		{TriedTwitterUserID: userID, TwitterTweetID: 2, StatusCode: http.StatusInternalServerError},
+		{TriedTwitterUserID: 2, TwitterTweetID: 3, StatusCode: http.StatusNotFound},
+	}
+	for i, ee := range ees {
+		insertID, err := s.service.Insert(ee)
+		s.NoError(err)
+		s.Equal(uint64(i+1), insertID)
+	}
+
+	ids := []uint64{1, 2, 3, 4}
+	tweetIDs, err := s.service.TweetNotFoundIDs(userID, ids)
+	s.NoError(err)
+	s.Equal([]uint64{1}, tweetIDs)
+
+	// Reopen the journal.
+	service, err := journal.NewEraseErrorService(s.dir)
+	s.NoError(err)
+
+	tweetIDs, err = service.TweetNotFoundIDs(userID, ids)
+	s.NoError(err)
+	s.Equal([]uint64{1}, tweetIDs)
+
+	// The last error of the duplicate tweet wins.
+	insertID, err := service.Insert(&model.EraseError{TriedTwitterUserID: userID,
+		TwitterTweetID: 1, StatusCode: http.StatusInternalServerError})
+	s.NoError(err)
+	s.Equal(uint64(1), insertID)
+
+	insertID, err = service.Insert(&model.EraseError{TriedTwitterUserID: userID,
+		TwitterTweetID: 2, StatusCode: http.StatusNotFound})
+	s.NoError(err)
+	s.Equal(uint64(2), insertID)
+
+	service, err = journal.NewEraseErrorService(s.dir)
+	s.NoError(err)
+
+	tweetIDs, err = service.TweetNotFoundIDs(userID, ids)
+	s.NoError(err)
+	s.Equal([]uint64{2}, tweetIDs)
+
+	// The next new error gets the next id of the lines.
+	insertID, err = service.Insert(&model.EraseError{TriedTwitterUserID: userID, TwitterTweetID: 4})
+	s.NoError(err)
+	s.Equal(uint64(6), insertID)
+}
+
+func (s *eraseErrorSuite) TestBulkInsert() {
+	userID := uint64(1)
+	cnt := 2500
+	ees := make([]*model.EraseError, cnt)
+	for i := 0; i < cnt; i++ {
+		ees[i] = &model.EraseError{TriedTwitterUserID: userID, TwitterTweetID: uint64(i + 1),
+			StatusCode: http.StatusNotFound, ErrorMessage: "Error: status 404."}
+	}
+
+	err := s.service.BulkInsert(ees)
+	s.NoError(err)
+
+	tweetIDs, err := s.service.TweetNotFoundIDs(userID, []uint64{1, uint64(cnt), uint64(cnt + 1)})
+	s.NoError(err)
+	s.Len(tweetIDs, 2)
+}
+
+func (s *eraseErrorSuite) TestEraseErrors() {
+	service := s.service.(*journal.EraseErrorService)
+	err := service.BulkInsert([]*model.EraseError{
+		{TriedTwitterUserID: 1, TwitterTweetID: 2, StatusCode: http.StatusInternalServerError},
+		{TriedTwitterUserID: 1, TwitterTweetID: 1, StatusCode: http.StatusInternalServerError},
+		{TriedTwitterUserID: 2, TwitterTweetID: 3, StatusCode: http.StatusNotFound},
+		{TriedTwitterUserID: 1, TwitterTweetID: 1, StatusCode: http.StatusNotFound},
+	})
+	s.NoError(err)
+
+	ees, err := service.EraseErrors(1)
+	s.NoError(err)
+	s.Len(ees, 2)
+	s.Equal(uint64(1), ees[0].TwitterTweetID)
+	s.Equal(uint32(2), ees[0].Attempts)
+	s.Equal(uint16(http.StatusNotFound), ees[0].StatusCode)
+	s.Equal(uint64(2), ees[1].TwitterTweetID)
+	s.Equal(uint32(1), ees[1].Attempts)
+}
+
+func (s *eraseErrorSuite) TestTweetStatusCodes() {
+	userID := uint64(1)
+	ees := []*model.EraseError{
+		{TriedTwitterUserID: userID, TwitterTweetID: 1, StatusCode: http.StatusNotFound},
+		{TriedTwitterUserID: userID, TwitterTweetID: 2, StatusCode: http.StatusNotFound},
+		{TriedTwitterUserID: userID, TwitterTweetID: 3},
+		{TriedTwitterUserID: userID, TwitterTweetID: 3, StatusCode: http.StatusInternalServerError},
+		{TriedTwitterUserID: 2, TwitterTweetID: 4, StatusCode: http.StatusForbidden},
+	}
+	err := s.service.BulkInsert(ees)
+	s.NoError(err)
+
+	// The status code of the last error of the tweet.
+	statusCodes, err := s.service.TweetStatusCodes(userID)
+	s.NoError(err)
+	s.Equal(map[uint64]uint16{1: http.StatusNotFound, 2: http.StatusNotFound, 3: http.StatusInternalServerError}, statusCodes)
+
+	// Not exist.
+	statusCodes, err = s.service.TweetStatusCodes(math.MaxUint64)
+	s.NoError(err)
+	s.Len(statusCodes, 0)
+}
+
+func (s *eraseErrorSuite) TestRetryTweetIDs() {
+	userID := uint64(1)
+	ees := []*model.EraseError{
+		{TriedTwitterUserID: userID, TwitterTweetID: 1, StatusCode: http.StatusNotFound},
+		{TriedTwitterUserID: userID, TwitterTweetID: 2, StatusCode: http.StatusInternalServerError},
+		{TriedTwitterUserID: userID, TwitterTweetID: 3},
+		{TriedTwitterUserID: 2, TwitterTweetID: 4, StatusCode: http.StatusForbidden},
+	}
+	err := s.service.BulkInsert(ees)
+	s.NoError(err)
+
+	tweetIDs, err := s.service.RetryTweetIDs(userID)
+	s.NoError(err)
+	s.Len(tweetIDs, 2)
+	s.Contains(tweetIDs, uint64(2))
+	s.Contains(tweetIDs, uint64(3))
+}
+
+func (s *eraseErrorSuite) TearDownTest() {
+	os.RemoveAll(s.dir)
+}
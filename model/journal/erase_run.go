@@ -0,0 +1,75 @@
+package journal
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/pkg/errors"
+)
+
+// EraseRunService is erase runs journal service.
+// An updated run is appended again with the same id, so the last record of the run is the current one.
+type EraseRunService struct {
+	j    *journal
+	mu   sync.Mutex
+	runs map[uint64]*model.EraseRun
+}
+
+// NewEraseRunService is create erase run service of the journal directory.
+func NewEraseRunService(dir string) (*EraseRunService, error) {
+	runs := map[uint64]*model.EraseRun{}
+	j, err := openJournal(dir, model.EraseRunTableName, func(line []byte) error {
+		er := &model.EraseRun{}
+		if err := json.Unmarshal(line, er); err != nil {
+			return err
+		}
+
+		runs[er.ID] = er
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &EraseRunService{j: j, runs: runs}, nil
+}
+
+// Insert appends the erase run to the journal.
+func (s *EraseRunService) Insert(er *model.EraseRun) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	record := *er
+	record.UpdatedAt, record.CreatedAt = now, now
+	id, err := s.j.append(&record, func(id uint64) { record.ID = id })
+	if err != nil {
+		return 0, err
+	}
+
+	s.runs[id] = &record
+	return id, nil
+}
+
+// Update appends the result counts and the ended time of the erase run.
+func (s *EraseRunService) Update(er *model.EraseRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.runs[er.ID]
+	if !ok {
+		return errors.Errorf("row not found: %d", er.ID)
+	}
+
+	record := *old
+	record.TargetCount, record.ErasedCount, record.ErrorCount = er.TargetCount, er.ErasedCount, er.ErrorCount
+	record.EndedAt, record.UpdatedAt = er.EndedAt, time.Now().UTC()
+	if _, err := s.j.append(&record, func(uint64) {}); err != nil {
+		return err
+	}
+
+	s.runs[er.ID] = &record
+	return nil
+}
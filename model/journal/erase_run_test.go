@@ -0,0 +1,63 @@
+package journal_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/journal"
+	"github.com/stretchr/testify/suite"
+)
+
+type eraseRunSuite struct {
+	suite.Suite
+
+	dir     string
+	service model.EraseRunService
+}
+
+func TestEraseRunSuite(t *testing.T) {
+	suite.Run(t, new(eraseRunSuite))
+}
+
+func (s *eraseRunSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "tweeraser_journal")
+	s.Require().NoError(err)
+
+	s.dir = dir
+	s.service, err = journal.NewEraseRunService(dir)
+	s.Require().NoError(err)
+}
+
+func (s *eraseRunSuite) TestInsertUpdate() {
+	er := &model.EraseRun{TwitterUserID: 1, Command: "erase", Sources: "timeline", StartedAt: time.Now().UTC()}
+	insertID, err := s.service.Insert(er)
+	s.NoError(err)
+	s.Equal(uint64(1), insertID)
+
+	endedAt := time.Now().UTC()
+	er.ID, er.TargetCount, er.ErasedCount, er.ErrorCount, er.EndedAt = insertID, 3, 2, 1, &endedAt
+	err = s.service.Update(er)
+	s.NoError(err)
+
+	// Not exist run.
+	err = s.service.Update(&model.EraseRun{ID: insertID + 1})
+	s.Error(err)
+
+	// Reopen the journal.
+	service, err := journal.NewEraseRunService(s.dir)
+	s.NoError(err)
+
+	insertID, err = service.Insert(&model.EraseRun{TwitterUserID: 1})
+	s.NoError(err)
+	s.Equal(uint64(3), insertID)
+
+	err = service.Update(er)
+	s.NoError(err)
+}
+
+func (s *eraseRunSuite) TearDownTest() {
+	os.RemoveAll(s.dir)
+}
@@ -0,0 +1,202 @@
+package journal
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+)
+
+// EraseTweetService is erase tweets journal service.
+type EraseTweetService struct {
+	mu     sync.Mutex
+	j      *journal
+	erased *idMap
+}
+
+// NewEraseTweetService is create erase tweet service of the journal directory.
+func NewEraseTweetService(dir string) (*EraseTweetService, error) {
+	erased := newIDMap()
+	j, err := openJournal(dir, model.EraseTweetTableName, func(line []byte) error {
+		var et model.EraseTweet
+		if err := json.Unmarshal(line, &et); err != nil {
+			return err
+		}
+
+		erased.set(et.TwitterUserID, et.TwitterTweetID, et.ID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &EraseTweetService{j: j, erased: erased}, nil
+}
+
+// AlreadyEraseTweetIDs return already erase ids from argument ids.
+func (s *EraseTweetService) AlreadyEraseTweetIDs(userID uint64, ids []uint64) ([]uint64, error) {
+	return s.erased.intersect(userID, ids), nil
+}
+
+// Insert appends the erase tweet to the journal.
+// If the tweet of the same user exists, the appended line keeps the id of it.
+func (s *EraseTweetService) Insert(et *model.EraseTweet) (uint64, error) {
+	if err := s.BulkInsert([]*model.EraseTweet{et}); err != nil {
+		return 0, err
+	}
+
+	id, _ := s.erased.get(et.TwitterUserID, et.TwitterTweetID)
+	return id, nil
+}
+
+// BulkInsert appends the erase tweets to the journal by one write.
+func (s *EraseTweetService) BulkInsert(ets []*model.EraseTweet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ets = model.UniqueEraseTweets(ets)
+	now := time.Now().UTC()
+	records := make([]model.EraseTweet, len(ets))
+	for i, et := range ets {
+		records[i] = *et
+		records[i].ID, _ = s.erased.get(et.TwitterUserID, et.TwitterTweetID)
+		records[i].UpdatedAt, records[i].CreatedAt = now, now
+	}
+
+	_, err := s.j.appendAll(len(records), func(i int) interface{} { return &records[i] }, func(i int, id uint64) {
+		if records[i].ID == 0 {
+			records[i].ID = id
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		s.erased.set(r.TwitterUserID, r.TwitterTweetID, r.ID)
+	}
+
+	return nil
+}
+
+// CountByPostedMonth counts the erased tweets of the user by the posted month.
+func (s *EraseTweetService) CountByPostedMonth(userID uint64) ([]*model.KeyCount, error) {
+	return s.countBy(userID, func(et *model.EraseTweet) string { return et.PostedAt.UTC().Format("2006-01") })
+}
+
+// CountBySource counts the erased tweets of the user by the source html of the raw json.
+// The tweets without raw json are counted as the empty source.
+func (s *EraseTweetService) CountBySource(userID uint64) ([]*model.KeyCount, error) {
+	return s.countBy(userID, func(et *model.EraseTweet) string {
+		var t struct {
+			Source string `json:"source"`
+		}
+
+		if err := json.Unmarshal([]byte(et.RawJSON), &t); err != nil {
+			return ""
+		}
+
+		return t.Source
+	})
+}
+
+// countBy reads back the journal because the tweets are not kept in memory.
+// The last line of the same id is the current record.
+func (s *EraseTweetService) countBy(userID uint64, key func(et *model.EraseTweet) string) ([]*model.KeyCount, error) {
+	keys := map[uint64]string{}
+	err := s.j.read(func(line []byte) error {
+		var et model.EraseTweet
+		if err := json.Unmarshal(line, &et); err != nil {
+			return err
+		}
+
+		if et.TwitterUserID == userID {
+			keys[et.ID] = key(&et)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return countKeys(keys), nil
+}
+
+// EraseTweets returns the erased tweets of the user in order of the posted time.
+// The created time is kept from the first line of the same id.
+func (s *EraseTweetService) EraseTweets(userID uint64) ([]*model.EraseTweet, error) {
+	records := map[uint64]*model.EraseTweet{}
+	err := s.j.read(func(line []byte) error {
+		var et model.EraseTweet
+		if err := json.Unmarshal(line, &et); err != nil {
+			return err
+		}
+
+		if et.TwitterUserID != userID {
+			return nil
+		}
+
+		if old, ok := records[et.ID]; ok {
+			et.CreatedAt = old.CreatedAt
+		}
+
+		records[et.ID] = &et
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ets := make(byPostedAt, 0, len(records))
+	for _, et := range records {
+		ets = append(ets, et)
+	}
+
+	sort.Sort(ets)
+	return ets, nil
+}
+
+// byPostedAt sorts the erase tweets by the posted time and the tweet id.
+type byPostedAt []*model.EraseTweet
+
+func (a byPostedAt) Len() int      { return len(a) }
+func (a byPostedAt) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byPostedAt) Less(i, j int) bool {
+	if a[i].PostedAt.Equal(a[j].PostedAt) {
+		return a[i].TwitterTweetID < a[j].TwitterTweetID
+	}
+
+	return a[i].PostedAt.Before(a[j].PostedAt)
+}
+
+// ScrubContent replaces the content of all lines of the erase tweets, so the former content does not remain in the journal.
+func (s *EraseTweetService) ScrubContent(ets []*model.EraseTweet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type key struct{ userID, tweetID uint64 }
+	scrubbed := map[key]*model.EraseTweet{}
+	for _, et := range ets {
+		scrubbed[key{et.TwitterUserID, et.TwitterTweetID}] = et
+	}
+
+	now := time.Now().UTC()
+	return s.j.rewrite(func(line []byte) ([]byte, error) {
+		var et model.EraseTweet
+		if err := json.Unmarshal(line, &et); err != nil {
+			return nil, err
+		}
+
+		sc, ok := scrubbed[key{et.TwitterUserID, et.TwitterTweetID}]
+		if !ok {
+			return line, nil
+		}
+
+		et.Tweet, et.TweetHash, et.RawJSON, et.MediaPaths = sc.Tweet, sc.TweetHash, sc.RawJSON, sc.MediaPaths
+		et.UpdatedAt = now
+		return json.Marshal(&et)
+	})
+}
@@ -0,0 +1,272 @@
+package journal_test
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/journal"
+	"github.com/stretchr/testify/suite"
+)
+
+type eraseTweetSuite struct {
+	suite.Suite
+
+	dir     string
+	service model.EraseTweetService
+}
+
+func TestEraseTweetSuite(t *testing.T) {
+	suite.Run(t, new(eraseTweetSuite))
+}
+
+func (s *eraseTweetSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "tweeraser_journal")
+	s.Require().NoError(err)
+
+	s.dir = dir
+	s.service, err = journal.NewEraseTweetService(dir)
+	s.Require().NoError(err)
+}
+
+func (s *eraseTweetSuite) TestAlreadyEraseTweetIDs() {
+	userID := uint64(1)
+	for i := 1; i <= 10; i++ {
+		et := &model.EraseTweet{TwitterTweetID: uint64(i), TwitterUserID: userID}
+		insertID, err := s.service.Insert(et)
+		s.NoError(err)
+		s.Equal(uint64(i), insertID)
+	}
+
+	// Other user.
+	insertID, err := s.service.Insert(&model.EraseTweet{TwitterTweetID: 100, TwitterUserID: 2})
+	s.NoError(err)
+	s.Equal(uint64(11), insertID)
+
+	ids := []uint64{1, 5, 10, 11, 100}
+	tweetIDs, err := s.service.AlreadyEraseTweetIDs(userID, ids)
+	s.NoError(err)
+	s.Equal([]uint64{1, 5, 10}, tweetIDs)
+
+	// Reopen the journal.
+	service, err := journal.NewEraseTweetService(s.dir)
+	s.NoError(err)
+
+	tweetIDs, err = service.AlreadyEraseTweetIDs(userID, ids)
+	s.NoError(err)
+	s.Equal([]uint64{1, 5, 10}, tweetIDs)
+
+	insertID, err = service.Insert(&model.EraseTweet{TwitterTweetID: 11, TwitterUserID: userID})
+	s.NoError(err)
+	s.Equal(uint64(12), insertID)
+
+	// Duplicate tweet keeps the id.
+	insertID, err = service.Insert(&model.EraseTweet{TwitterTweetID: 5, TwitterUserID: userID})
+	s.NoError(err)
+	s.Equal(uint64(5), insertID)
+
+	service, err = journal.NewEraseTweetService(s.dir)
+	s.NoError(err)
+
+	insertID, err = service.Insert(&model.EraseTweet{TwitterTweetID: 5, TwitterUserID: userID})
+	s.NoError(err)
+	s.Equal(uint64(5), insertID)
+}
+
+func (s *eraseTweetSuite) TestBulkInsert() {
+	userID := uint64(1)
+	cnt := 2500
+	ets := make([]*model.EraseTweet, cnt)
+	for i := 0; i < cnt; i++ {
+		ets[i] = &model.EraseTweet{TwitterTweetID: uint64(i + 1), Tweet: "tweet",
+			PostedAt: time.Now().UTC(), TwitterUserID: userID}
+	}
+
+	err := s.service.BulkInsert(ets)
+	s.NoError(err)
+
+	tweetIDs, err := s.service.AlreadyEraseTweetIDs(userID, []uint64{1, uint64(cnt), uint64(cnt + 1)})
+	s.NoError(err)
+	s.Len(tweetIDs, 2)
+}
+
+func (s *eraseTweetSuite) TestCountByPostedMonth() {
+	userID := uint64(1)
+	ets := []*model.EraseTweet{
+		{TwitterTweetID: 1, PostedAt: time.Date(2016, 12, 31, 23, 59, 59, 0, time.UTC), TwitterUserID: userID},
+		{TwitterTweetID: 2, PostedAt: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), TwitterUserID: userID},
+		{TwitterTweetID: 3, PostedAt: time.Date(2017, 1, 31, 0, 0, 0, 0, time.UTC), TwitterUserID: userID},
+		{TwitterTweetID: 4, PostedAt: time.Date(2017, 1, 31, 0, 0, 0, 0, time.UTC), TwitterUserID: 2},
+	}
+	err := s.service.BulkInsert(ets)
+	s.NoError(err)
+
+	// Duplicate tweet is counted once.
+	_, err = s.service.Insert(ets[2])
+	s.NoError(err)
+
+	kcs, err := s.service.CountByPostedMonth(userID)
+	s.NoError(err)
+	s.Equal([]*model.KeyCount{{Key: "2016-12", Count: 1}, {Key: "2017-01", Count: 2}}, kcs)
+
+	// Not exist.
+	kcs, err = s.service.CountByPostedMonth(math.MaxUint64)
+	s.NoError(err)
+	s.Len(kcs, 0)
+}
+
+func (s *eraseTweetSuite) TestCountBySource() {
+	userID := uint64(1)
+	web := `<a href="http://twitter.com" rel="nofollow">Twitter Web Client</a>`
+	ets := []*model.EraseTweet{
+		{TwitterTweetID: 1, RawJSON: `{"source":"` + strings.Replace(web, `"`, `\"`, -1) + `"}`,
+			PostedAt: time.Now().UTC(), TwitterUserID: userID},
+		{TwitterTweetID: 2, RawJSON: `{"text":"no source"}`, PostedAt: time.Now().UTC(), TwitterUserID: userID},
+		{TwitterTweetID: 3, PostedAt: time.Now().UTC(), TwitterUserID: userID},
+		{TwitterTweetID: 4, RawJSON: `{"source":"web"}`, PostedAt: time.Now().UTC(), TwitterUserID: 2},
+	}
+	err := s.service.BulkInsert(ets)
+	s.NoError(err)
+
+	kcs, err := s.service.CountBySource(userID)
+	s.NoError(err)
+	s.Equal([]*model.KeyCount{{Key: "", Count: 2}, {Key: web, Count: 1}}, kcs)
+}
+
+func (s *eraseTweetSuite) TestEraseTweets() {
+	userID := uint64(1)
+	postedAt := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	ets := []*model.EraseTweet{
+		{TwitterTweetID: 3, Tweet: "third", PostedAt: postedAt.Add(time.Hour), TwitterUserID: userID},
+		{TwitterTweetID: 2, Tweet: "second", RawJSON: `{"id":2}`, MediaPaths: "media/ab/ab.jpg",
+			PostedAt: postedAt, TwitterUserID: userID},
+		{TwitterTweetID: 1, Tweet: "first", PostedAt: postedAt, TwitterUserID: userID},
+		{TwitterTweetID: 4, Tweet: "other user", PostedAt: postedAt, TwitterUserID: 2},
+	}
+	err := s.service.BulkInsert(ets)
+	s.NoError(err)
+
+	// Duplicate tweet is updated.
+	ets[0].Tweet = "updated"
+	_, err = s.service.Insert(ets[0])
+	s.NoError(err)
+
+	actual, err := s.service.EraseTweets(userID)
+	s.NoError(err)
+	s.Len(actual, 3)
+
+	for i, expected := range []*model.EraseTweet{ets[2], ets[1], ets[0]} {
+		s.Equal(expected.TwitterTweetID, actual[i].TwitterTweetID)
+		s.Equal(expected.Tweet, actual[i].Tweet)
+		s.Equal(expected.RawJSON, actual[i].RawJSON)
+		s.Equal(expected.MediaPaths, actual[i].MediaPaths)
+		s.WithinDuration(expected.PostedAt, actual[i].PostedAt, 0)
+		s.Equal(userID, actual[i].TwitterUserID)
+		s.NotZero(actual[i].ID)
+		s.False(actual[i].CreatedAt.IsZero())
+	}
+
+	// Not exist.
+	actual, err = s.service.EraseTweets(math.MaxUint64)
+	s.NoError(err)
+	s.Len(actual, 0)
+}
+
+func (s *eraseTweetSuite) TestScrubContent() {
+	userID := uint64(1)
+	ets := []*model.EraseTweet{
+		{TwitterTweetID: 1, Tweet: "secret", RawJSON: `{"text":"secret"}`, PostedAt: time.Now().UTC(), TwitterUserID: userID},
+		{TwitterTweetID: 2, Tweet: "kept", PostedAt: time.Now().UTC(), TwitterUserID: userID},
+	}
+	err := s.service.BulkInsert(ets)
+	s.NoError(err)
+
+	erased, err := s.service.EraseTweets(userID)
+	s.NoError(err)
+	s.Len(erased, 2)
+
+	scrubbed := erased[0]
+	if scrubbed.TwitterTweetID != 1 {
+		scrubbed = erased[1]
+	}
+
+	id := scrubbed.ID
+	scrubbed.StoreContent(model.StoreContentHash, "salt")
+	err = s.service.ScrubContent([]*model.EraseTweet{scrubbed})
+	s.NoError(err)
+
+	actual, err := s.service.EraseTweets(userID)
+	s.NoError(err)
+	s.Len(actual, 2)
+
+	for _, et := range actual {
+		if et.TwitterTweetID != 1 {
+			s.Equal("kept", et.Tweet)
+			s.Equal("", et.TweetHash)
+			continue
+		}
+
+		s.Equal(id, et.ID)
+		s.Equal("", et.Tweet)
+		s.Equal("", et.RawJSON)
+		s.Equal(model.TweetHash("salt", "secret"), et.TweetHash)
+	}
+
+	// The former content does not remain in the journal.
+	b, err := ioutil.ReadFile(filepath.Join(s.dir, model.EraseTweetTableName+".jsonl"))
+	s.NoError(err)
+	s.NotContains(string(b), "secret")
+
+	// Reopen the journal.
+	service, err := journal.NewEraseTweetService(s.dir)
+	s.NoError(err)
+
+	insertID, err := service.Insert(&model.EraseTweet{TwitterTweetID: 3, TwitterUserID: userID})
+	s.NoError(err)
+	s.Equal(uint64(3), insertID)
+}
+
+func (s *eraseTweetSuite) TestTornLastLine() {
+	userID := uint64(1)
+	for i := 1; i <= 2; i++ {
+		_, err := s.service.Insert(&model.EraseTweet{TwitterTweetID: uint64(i), TwitterUserID: userID})
+		s.NoError(err)
+	}
+
+	// The last line torn by a crash while appending is truncated.
+	path := filepath.Join(s.dir, model.EraseTweetTableName+".jsonl")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	s.Require().NoError(err)
+	_, err = f.WriteString(`{"ID":3,"TwitterTweetID":3,"Tw`)
+	s.NoError(err)
+	s.NoError(f.Close())
+
+	service, err := journal.NewEraseTweetService(s.dir)
+	s.Require().NoError(err)
+
+	tweetIDs, err := service.AlreadyEraseTweetIDs(userID, []uint64{1, 2, 3})
+	s.NoError(err)
+	s.Equal([]uint64{1, 2}, tweetIDs)
+
+	insertID, err := service.Insert(&model.EraseTweet{TwitterTweetID: 3, TwitterUserID: userID})
+	s.NoError(err)
+	s.Equal(uint64(3), insertID)
+
+	b, err := ioutil.ReadFile(path)
+	s.NoError(err)
+	s.Len(strings.Split(strings.TrimSpace(string(b)), "\n"), 3)
+
+	// The corruption in the middle of the file is the error.
+	s.NoError(ioutil.WriteFile(path, []byte("{\"ID\":1}\n{broken\n{\"ID\":3}\n"), 0644))
+	_, err = journal.NewEraseTweetService(s.dir)
+	s.Error(err)
+}
+
+func (s *eraseTweetSuite) TearDownTest() {
+	os.RemoveAll(s.dir)
+}
@@ -0,0 +1,285 @@
+// Package journal implements the model services on append-only JSONL files.
+// It is the fallback store when no database is available.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/pkg/errors"
+)
+
+// maxLineSize is the max size of one record. The raw json of a tweet fits in it.
+const maxLineSize = 16 * 1024 * 1024
+
+// journal is an append-only JSONL file of one table.
+type journal struct {
+	mu     sync.Mutex
+	path   string
+	lastID uint64
+}
+
+// openJournal reads all records of <dir>/<tableName>.jsonl and passes each line to fn.
+// The file is created on the first append.
+// The last line which fn fails on is the record torn by a crash while appending, so it is truncated.
+// The line which fn fails on in the middle of the file is the corruption and returns the error.
+func openJournal(dir, tableName string, fn func(line []byte) error) (*journal, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	j := &journal{path: filepath.Join(dir, tableName+".jsonl")}
+	f, err := os.OpenFile(j.path, os.O_RDWR, 0644)
+	if os.IsNotExist(err) {
+		return j, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var offset, tornOffset int64
+	var tornErr error
+	terminated := true
+	r := bufio.NewReader(f)
+	for lineNum := 1; ; lineNum++ {
+		b, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		} else if len(b) == 0 {
+			break
+		}
+
+		if tornErr != nil {
+			return nil, errors.Wrapf(tornErr, "%s:%d", j.path, lineNum-1)
+		}
+
+		terminated = b[len(b)-1] == '\n'
+		if line := bytes.TrimRight(b, "\r\n"); len(line) > 0 {
+			if len(line) > maxLineSize {
+				return nil, errors.Errorf("%s:%d: line is too long", j.path, lineNum)
+			} else if err := fn(line); err != nil {
+				tornOffset, tornErr = offset, err
+			} else {
+				j.lastID++
+			}
+		}
+
+		offset += int64(len(b))
+	}
+
+	if tornErr != nil {
+		if err := f.Truncate(tornOffset); err != nil {
+			return nil, err
+		}
+	} else if !terminated {
+		// The last record is complete but its newline is lost, so terminate it before the next append.
+		if _, err := f.WriteAt([]byte{'\n'}, offset); err != nil {
+			return nil, err
+		}
+	}
+
+	return j, nil
+}
+
+// read passes each line of the journal to fn. The journal not yet written has no lines.
+func (j *journal) read(fn func(line []byte) error) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	f, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 64*1024), maxLineSize)
+	for s.Scan() {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+
+		if err := fn(s.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return s.Err()
+}
+
+// rewrite replaces each line of the journal with the line returned by fn.
+// The lines are kept in order, so the sequential ids do not change.
+// The new journal is written to a temporary file and renamed, so the former content does not remain in the file.
+func (j *journal) rewrite(fn func(line []byte) ([]byte, error)) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	f, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	tmp, err := ioutil.TempFile(filepath.Dir(j.path), "."+filepath.Base(j.path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 64*1024), maxLineSize)
+	for s.Scan() {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+
+		line, err := fn(s.Bytes())
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+
+	if err := s.Err(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), j.path)
+}
+
+// append writes v as one line and returns the sequential id of the record.
+// setID is called with the id before writing.
+func (j *journal) append(v interface{}, setID func(id uint64)) (uint64, error) {
+	return j.appendAll(1, func(int) interface{} { return v }, func(_ int, id uint64) { setID(id) })
+}
+
+// appendAll writes n records by one write and returns the last id.
+// record returns the i-th record and setID is called with the id of it before writing.
+// A record may keep the id of the former line to upsert it, the last line wins on reading.
+func (j *journal) appendAll(n int, record func(i int) interface{}, setID func(i int, id uint64)) (uint64, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var buf bytes.Buffer
+	id := j.lastID
+	for i := 0; i < n; i++ {
+		id++
+		setID(i, id)
+
+		b, err := json.Marshal(record(i))
+		if err != nil {
+			return 0, err
+		}
+
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return 0, err
+	}
+
+	if err := f.Close(); err != nil {
+		return 0, err
+	}
+
+	j.lastID = id
+	return id, nil
+}
+
+// idMap is the record id by user id and tweet id.
+type idMap struct {
+	mu  sync.RWMutex
+	ids map[uint64]map[uint64]uint64
+}
+
+func newIDMap() *idMap {
+	return &idMap{ids: map[uint64]map[uint64]uint64{}}
+}
+
+func (m *idMap) set(userID, tweetID, id uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ids[userID] == nil {
+		m.ids[userID] = map[uint64]uint64{}
+	}
+
+	m.ids[userID][tweetID] = id
+}
+
+func (m *idMap) get(userID, tweetID uint64) (uint64, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	id, ok := m.ids[userID][tweetID]
+	return id, ok
+}
+
+// intersect returns the tweet ids of the user contained in the map.
+func (m *idMap) intersect(userID uint64, tweetIDs []uint64) []uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var contained []uint64
+	for _, id := range tweetIDs {
+		if _, ok := m.ids[userID][id]; ok {
+			contained = append(contained, id)
+		}
+	}
+
+	return contained
+}
+
+// countKeys counts the keys by the value and sorts the counts by the key.
+func countKeys(keys map[uint64]string) []*model.KeyCount {
+	counts := map[string]uint64{}
+	for _, k := range keys {
+		counts[k]++
+	}
+
+	var sorted []string
+	for k := range counts {
+		sorted = append(sorted, k)
+	}
+
+	sort.Strings(sorted)
+	kcs := make([]*model.KeyCount, len(sorted))
+	for i, k := range sorted {
+		kcs[i] = &model.KeyCount{Key: k, Count: counts[k]}
+	}
+
+	return kcs
+}
@@ -0,0 +1,82 @@
+package journal
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+)
+
+// TwitterUserService is twitter users journal service.
+// A changed user is appended again, so the last record of the user is the current one.
+type TwitterUserService struct {
+	j     *journal
+	mu    sync.Mutex
+	users map[uint64]*model.TwitterUser
+}
+
+// NewTwitterUserService is create twitter user service of the journal directory.
+func NewTwitterUserService(dir string) (*TwitterUserService, error) {
+	users := map[uint64]*model.TwitterUser{}
+	j, err := openJournal(dir, model.TwitterUserTableName, func(line []byte) error {
+		tu := &model.TwitterUser{}
+		if err := json.Unmarshal(line, tu); err != nil {
+			return err
+		}
+
+		users[tu.UserID] = tu
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TwitterUserService{j: j, users: users}, nil
+}
+
+// InsertUpdate appends the user if the user is new or changed.
+func (s *TwitterUserService) InsertUpdate(tu *model.TwitterUser) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	record := *tu
+	record.UpdatedAt, record.CreatedAt = now, now
+	if dbtu, ok := s.users[tu.UserID]; ok {
+		if !tu.CheckWantUpdate(dbtu) {
+			return nil
+		}
+
+		record.CreatedAt = dbtu.CreatedAt
+	}
+
+	if _, err := s.j.append(&record, func(uint64) {}); err != nil {
+		return err
+	}
+
+	s.users[record.UserID] = &record
+	return nil
+}
+
+// TwitterUsers returns the current records of the users in order of the user id.
+func (s *TwitterUserService) TwitterUsers() ([]*model.TwitterUser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tus := make(byUserID, 0, len(s.users))
+	for _, tu := range s.users {
+		record := *tu
+		tus = append(tus, &record)
+	}
+	sort.Sort(tus)
+
+	return tus, nil
+}
+
+type byUserID []*model.TwitterUser
+
+func (a byUserID) Len() int           { return len(a) }
+func (a byUserID) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byUserID) Less(i, j int) bool { return a[i].UserID < a[j].UserID }
@@ -0,0 +1,81 @@
+package journal_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/journal"
+	"github.com/stretchr/testify/suite"
+)
+
+type twitterUserSuite struct {
+	suite.Suite
+
+	dir     string
+	service model.TwitterUserService
+}
+
+func TestTwitterUserSuite(t *testing.T) {
+	suite.Run(t, new(twitterUserSuite))
+}
+
+func (s *twitterUserSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "tweeraser_journal")
+	s.Require().NoError(err)
+
+	s.dir = dir
+	s.service, err = journal.NewTwitterUserService(dir)
+	s.Require().NoError(err)
+}
+
+func (s *twitterUserSuite) TestInsertUpdate() {
+	tu := &model.TwitterUser{UserID: 1, ScreenName: "screen_name", Name: "name", Lang: "en"}
+	s.NoError(s.service.InsertUpdate(tu))
+
+	// Not changed.
+	s.NoError(s.service.InsertUpdate(tu))
+	s.Equal(1, s.lineCount())
+
+	// Changed.
+	s.NoError(s.service.InsertUpdate(&model.TwitterUser{UserID: 1, Name: "name_dup"}))
+	s.Equal(2, s.lineCount())
+
+	// Reopen the journal and the last record is the current user.
+	service, err := journal.NewTwitterUserService(s.dir)
+	s.NoError(err)
+	s.NoError(service.InsertUpdate(&model.TwitterUser{UserID: 1, Name: "name_dup"}))
+	s.Equal(2, s.lineCount())
+}
+
+func (s *twitterUserSuite) TestTwitterUsers() {
+	tus, err := s.service.TwitterUsers()
+	s.NoError(err)
+	s.Empty(tus)
+
+	s.NoError(s.service.InsertUpdate(&model.TwitterUser{UserID: 2, ScreenName: "second", Name: "name2", Lang: "ja"}))
+	s.NoError(s.service.InsertUpdate(&model.TwitterUser{UserID: 1, ScreenName: "first", Name: "name1", Lang: "en"}))
+
+	tus, err = s.service.TwitterUsers()
+	s.NoError(err)
+	s.Len(tus, 2)
+	s.Equal(uint64(1), tus[0].UserID)
+	s.Equal("first", tus[0].ScreenName)
+	s.Equal("name1", tus[0].Name)
+	s.Equal("en", tus[0].Lang)
+	s.Equal(uint64(2), tus[1].UserID)
+	s.Equal("second", tus[1].ScreenName)
+}
+
+func (s *twitterUserSuite) lineCount() int {
+	b, err := ioutil.ReadFile(filepath.Join(s.dir, model.TwitterUserTableName+".jsonl"))
+	s.Require().NoError(err)
+	return strings.Count(string(b), "\n")
+}
+
+func (s *twitterUserSuite) TearDownTest() {
+	os.RemoveAll(s.dir)
+}
@@ -0,0 +1,7 @@
+package model
+
+// KeyCount is the count of the rows grouped by the key.
+type KeyCount struct {
+	Key   string
+	Count uint64
+}
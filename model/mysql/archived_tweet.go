@@ -0,0 +1,157 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	sq "github.com/Masterminds/squirrel"
+)
+
+// bulkInsertRowCnt is the number of rows inserted by one statement.
+const bulkInsertRowCnt = 1000
+
+// ArchivedTweetService is archived tweets table service.
+type ArchivedTweetService struct {
+	pr prepareRunner
+}
+
+// NewArchivedTweetService is create archived tweet service.
+func NewArchivedTweetService(db *sql.DB) ArchivedTweetService {
+	return ArchivedTweetService{pr: newPrepareRunner(db)}
+}
+
+// BulkInsertUpdate inserts archived tweets with multi-row statements.
+// Already archived tweets are updated with the argument values.
+func (s ArchivedTweetService) BulkInsertUpdate(ats []*model.ArchivedTweet) error {
+	for len(ats) > 0 {
+		cnt := bulkInsertRowCnt
+		if len(ats) < cnt {
+			cnt = len(ats)
+		}
+
+		if err := s.bulkInsertUpdate(ats[:cnt]); err != nil {
+			return err
+		}
+
+		ats = ats[cnt:]
+	}
+
+	return nil
+}
+
+func (s ArchivedTweetService) bulkInsertUpdate(ats []*model.ArchivedTweet) error {
+	now := time.Now().UTC()
+	b := sq.Insert(model.ArchivedTweetTableName).Columns(
+		"twitter_user_id", "twitter_tweet_id", "in_reply_to_status_id", "in_reply_to_user_id",
+		"retweeted_status_id", "retweeted_status_user_id", "source", "tweet", "expanded_urls",
+		"posted_at", "updated_at", "created_at")
+	for _, at := range ats {
+		b = b.Values(at.TwitterUserID, at.TwitterTweetID, at.InReplyToStatusID, at.InReplyToUserID,
+			at.RetweetedStatusID, at.RetweetedStatusUserID, at.Source, at.Tweet, at.ExpandedURLs,
+			at.PostedAt, now, now)
+	}
+
+	query, args, err := b.Suffix("ON DUPLICATE KEY UPDATE " +
+		"in_reply_to_status_id = VALUES(in_reply_to_status_id), " +
+		"in_reply_to_user_id = VALUES(in_reply_to_user_id), " +
+		"retweeted_status_id = VALUES(retweeted_status_id), " +
+		"retweeted_status_user_id = VALUES(retweeted_status_user_id), " +
+		"source = VALUES(source), tweet = VALUES(tweet), expanded_urls = VALUES(expanded_urls), " +
+		"posted_at = VALUES(posted_at), updated_at = VALUES(updated_at)").ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.pr.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InteractedUserIDs returns the user ids that the user replied to or retweeted.
+func (s ArchivedTweetService) InteractedUserIDs(userID uint64) ([]uint64, error) {
+	replyQuery, replyArgs, err := sq.Select("in_reply_to_user_id").From(model.ArchivedTweetTableName).
+		Where(sq.And{sq.Eq{"twitter_user_id": userID}, sq.NotEq{"in_reply_to_user_id": 0}}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	retweetQuery, retweetArgs, err := sq.Select("retweeted_status_user_id").From(model.ArchivedTweetTableName).
+		Where(sq.And{sq.Eq{"twitter_user_id": userID}, sq.NotEq{"retweeted_status_user_id": 0}}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pr.Query(replyQuery+" UNION "+retweetQuery, append(replyArgs, retweetArgs...)...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []uint64
+	for rows.Next() {
+		var id uint64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		userIDs = append(userIDs, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return userIDs, nil
+}
+
+// TweetIDs returns the archived tweet ids of the user.
+func (s ArchivedTweetService) TweetIDs(userID uint64) ([]uint64, error) {
+	query, args, err := sq.Select("twitter_tweet_id").From(model.ArchivedTweetTableName).
+		Where(sq.Eq{"twitter_user_id": userID}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pr.Query(query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tweetIDs []uint64
+	for rows.Next() {
+		var id uint64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		tweetIDs = append(tweetIDs, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return tweetIDs, nil
+}
+
+// ScrubErasedContent empties the text of the archived tweets which are erased, and returns the number of the scrubbed tweets.
+// The text of the tweets not erased yet is kept for --query.
+func (s ArchivedTweetService) ScrubErasedContent(userID uint64) (uint64, error) {
+	query := "UPDATE " + model.ArchivedTweetTableName + " a JOIN " + model.EraseTweetTableName + " e " +
+		"ON e.twitter_user_id = a.twitter_user_id AND e.twitter_tweet_id = a.twitter_tweet_id " +
+		"SET a.tweet = '', a.expanded_urls = '', a.updated_at = ? WHERE a.twitter_user_id = ? AND a.tweet != ''"
+	return execRowsAffected(s.pr, query, []interface{}{time.Now().UTC(), userID})
+}
@@ -0,0 +1,212 @@
+package mysql_test
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/mysql"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/suite"
+)
+
+type archivedTweetSuite struct {
+	suite.Suite
+
+	db      *sql.DB
+	service model.ArchivedTweetService
+}
+
+func TestArchivedTweetSuite(t *testing.T) {
+	suite.Run(t, new(archivedTweetSuite))
+}
+
+func (s *archivedTweetSuite) SetupSuite() {
+	db, err := openTestDB()
+	s.NoError(err)
+
+	s.db = db
+	s.service = mysql.NewArchivedTweetService(db)
+}
+
+func (s *archivedTweetSuite) SetupTest() {
+	// Reset test db.
+	_, err := s.db.Exec("SET FOREIGN_KEY_CHECKS = 0")
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.ArchivedTweetTableName))
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.EraseTweetTableName))
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.TwitterUserTableName))
+	s.NoError(err)
+	_, err = s.db.Exec("SET FOREIGN_KEY_CHECKS = 1")
+	s.NoError(err)
+
+	// Create test twitter users.
+	tus := mysql.NewTwitterUserService(s.db)
+	for _, uid := range []uint64{1, 2, math.MaxUint64} {
+		tu := &model.TwitterUser{UserID: uid}
+		err = tus.InsertUpdate(tu)
+		s.NoError(err)
+	}
+}
+
+func (s *archivedTweetSuite) TestBulkInsertUpdate() {
+	userID := uint64(1)
+	cnt := 2500
+	ats := make([]*model.ArchivedTweet, cnt)
+	for i := 0; i < cnt; i++ {
+		ats[i] = &model.ArchivedTweet{TwitterUserID: userID, TwitterTweetID: math.MaxUint64 - uint64(i),
+			Tweet: "tweet", PostedAt: time.Now().UTC()}
+	}
+
+	err := s.service.BulkInsertUpdate(ats)
+	s.NoError(err)
+
+	var actualCnt int
+	err = sq.Select("COUNT(*)").From(model.ArchivedTweetTableName).
+		Where(sq.Eq{"twitter_user_id": userID}).RunWith(s.db).QueryRow().Scan(&actualCnt)
+	s.NoError(err)
+	s.Equal(cnt, actualCnt)
+
+	// Duplicate update.
+	postedAt := time.Now().Add(-24 * time.Hour).UTC()
+	at := &model.ArchivedTweet{TwitterUserID: userID, TwitterTweetID: math.MaxUint64,
+		InReplyToStatusID: 1, InReplyToUserID: 2, RetweetedStatusID: 3, RetweetedStatusUserID: 4,
+		Source: `<a href="http://twitter.com" rel="nofollow">Twitter Web Client</a>`,
+		Tweet:  "tweet_dup", ExpandedURLs: "https://example.com/1,https://example.com/2", PostedAt: postedAt}
+	err = s.service.BulkInsertUpdate([]*model.ArchivedTweet{at})
+	s.NoError(err)
+
+	rows, err := sq.Select("*").From(model.ArchivedTweetTableName).
+		Where(sq.Eq{"twitter_tweet_id": at.TwitterTweetID}).RunWith(s.db).Query()
+	s.NoError(err)
+
+	actualCnt = 0
+	for rows.Next() {
+		var actual model.ArchivedTweet
+		err := rows.Scan(&actual.ID, &actual.TwitterUserID, &actual.TwitterTweetID,
+			&actual.InReplyToStatusID, &actual.InReplyToUserID, &actual.RetweetedStatusID,
+			&actual.RetweetedStatusUserID, &actual.Source, &actual.Tweet, &actual.ExpandedURLs,
+			&actual.PostedAt, &actual.UpdatedAt, &actual.CreatedAt)
+		s.NoError(err)
+
+		s.Equal(uint64(1), actual.ID)
+		s.Equal(at.TwitterUserID, actual.TwitterUserID)
+		s.Equal(at.InReplyToStatusID, actual.InReplyToStatusID)
+		s.Equal(at.InReplyToUserID, actual.InReplyToUserID)
+		s.Equal(at.RetweetedStatusID, actual.RetweetedStatusID)
+		s.Equal(at.RetweetedStatusUserID, actual.RetweetedStatusUserID)
+		s.Equal(at.Source, actual.Source)
+		s.Equal(at.Tweet, actual.Tweet)
+		s.Equal(at.ExpandedURLs, actual.ExpandedURLs)
+		s.WithinDuration(at.PostedAt.Truncate(time.Second), actual.PostedAt, 0)
+
+		actualCnt++
+	}
+
+	s.Equal(1, actualCnt)
+	s.NoError(rows.Err())
+	s.NoError(rows.Close())
+
+	// Not exist user.
+	err = s.service.BulkInsertUpdate([]*model.ArchivedTweet{{TwitterUserID: 3}})
+	s.Error(err)
+}
+
+func (s *archivedTweetSuite) TestInteractedUserIDs() {
+	userID := uint64(1)
+	now := time.Now().UTC()
+	ats := []*model.ArchivedTweet{
+		{TwitterUserID: userID, TwitterTweetID: 1, InReplyToUserID: 10, PostedAt: now},
+		{TwitterUserID: userID, TwitterTweetID: 2, RetweetedStatusUserID: 20, PostedAt: now},
+		{TwitterUserID: userID, TwitterTweetID: 3, InReplyToUserID: 20, PostedAt: now},
+		{TwitterUserID: userID, TwitterTweetID: 4, PostedAt: now},
+		{TwitterUserID: 2, TwitterTweetID: 5, InReplyToUserID: 30, PostedAt: now},
+	}
+	err := s.service.BulkInsertUpdate(ats)
+	s.NoError(err)
+
+	userIDs, err := s.service.InteractedUserIDs(userID)
+	s.NoError(err)
+	s.Len(userIDs, 2)
+	s.Contains(userIDs, uint64(10))
+	s.Contains(userIDs, uint64(20))
+
+	// Not exist.
+	userIDs, err = s.service.InteractedUserIDs(math.MaxUint64)
+	s.NoError(err)
+	s.Len(userIDs, 0)
+}
+
+func (s *archivedTweetSuite) TestTweetIDs() {
+	userID := uint64(1)
+	now := time.Now().UTC()
+	ats := []*model.ArchivedTweet{
+		{TwitterUserID: userID, TwitterTweetID: 1, PostedAt: now},
+		{TwitterUserID: userID, TwitterTweetID: math.MaxUint64, PostedAt: now},
+		{TwitterUserID: 2, TwitterTweetID: 3, PostedAt: now},
+	}
+	err := s.service.BulkInsertUpdate(ats)
+	s.NoError(err)
+
+	tweetIDs, err := s.service.TweetIDs(userID)
+	s.NoError(err)
+	s.Len(tweetIDs, 2)
+	s.Contains(tweetIDs, uint64(1))
+	s.Contains(tweetIDs, uint64(math.MaxUint64))
+
+	// Not exist.
+	tweetIDs, err = s.service.TweetIDs(math.MaxUint64)
+	s.NoError(err)
+	s.Len(tweetIDs, 0)
+}
+
+func (s *archivedTweetSuite) TestScrubErasedContent() {
+	userID := uint64(1)
+	now := time.Now().UTC()
+	ats := []*model.ArchivedTweet{
+		{TwitterUserID: userID, TwitterTweetID: 1, Tweet: "erased", ExpandedURLs: "https://example.com", PostedAt: now},
+		{TwitterUserID: userID, TwitterTweetID: 2, Tweet: "not erased", PostedAt: now},
+		{TwitterUserID: 2, TwitterTweetID: 1, Tweet: "other user", PostedAt: now},
+	}
+	err := s.service.BulkInsertUpdate(ats)
+	s.NoError(err)
+
+	_, err = mysql.NewEraseTweetService(s.db).Insert(&model.EraseTweet{TwitterUserID: userID,
+		TwitterTweetID: 1, PostedAt: now})
+	s.NoError(err)
+
+	cnt, err := s.service.ScrubErasedContent(userID)
+	s.NoError(err)
+	s.Equal(uint64(1), cnt)
+
+	rows, err := sq.Select("twitter_user_id", "twitter_tweet_id", "tweet", "expanded_urls").
+		From(model.ArchivedTweetTableName).OrderBy("id").RunWith(s.db).Query()
+	s.NoError(err)
+
+	var tweets []string
+	for rows.Next() {
+		var actual model.ArchivedTweet
+		err := rows.Scan(&actual.TwitterUserID, &actual.TwitterTweetID, &actual.Tweet, &actual.ExpandedURLs)
+		s.NoError(err)
+
+		tweets = append(tweets, actual.Tweet+actual.ExpandedURLs)
+	}
+
+	s.Equal([]string{"", "not erased", "other user"}, tweets)
+	s.NoError(rows.Err())
+	s.NoError(rows.Close())
+
+	// Already scrubbed.
+	cnt, err = s.service.ScrubErasedContent(userID)
+	s.NoError(err)
+	s.Equal(uint64(0), cnt)
+}
+
+func (s *archivedTweetSuite) TearDownSuite() {
+	s.db.Close()
+}
@@ -0,0 +1,91 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	sq "github.com/Masterminds/squirrel"
+)
+
+// EraseDirectMessageService is erase direct messages table service.
+type EraseDirectMessageService struct {
+	pr prepareRunner
+}
+
+// NewEraseDirectMessageService is create erase direct message service.
+func NewEraseDirectMessageService(db *sql.DB) EraseDirectMessageService {
+	return EraseDirectMessageService{pr: newPrepareRunner(db)}
+}
+
+// AlreadyEraseDirectMessageIDs return already erase direct message ids from argument ids.
+func (s EraseDirectMessageService) AlreadyEraseDirectMessageIDs(userID uint64, ids []uint64) ([]uint64, error) {
+	query, args, err := sq.Select("twitter_direct_message_id").From(model.EraseDirectMessageTableName).
+		Where(sq.Eq{"twitter_user_id": userID, "twitter_direct_message_id": ids}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pr.Query(query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messageIDs []uint64
+	for rows.Next() {
+		var id uint64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		messageIDs = append(messageIDs, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return messageIDs, nil
+}
+
+// Insert is insert erase_direct_messages table.
+func (s EraseDirectMessageService) Insert(edm *model.EraseDirectMessage) (uint64, error) {
+	now := time.Now().UTC()
+	query, args, err := sq.Insert(model.EraseDirectMessageTableName).Columns(
+		"twitter_direct_message_id", "conversation_id", "sender_id", "recipient_id",
+		"message", "sent_at", "twitter_user_id", "updated_at", "created_at").
+		Values(edm.TwitterDirectMessageID, edm.ConversationID, edm.SenderID, edm.RecipientID,
+			edm.Message, edm.SentAt, edm.TwitterUserID, now, now).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := s.pr.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	lastInsertID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastInsertID), nil
+}
+
+// ScrubContent empties the text of the erased direct messages, and returns the number of the scrubbed messages.
+func (s EraseDirectMessageService) ScrubContent(userID uint64) (uint64, error) {
+	query, args, err := sq.Update(model.EraseDirectMessageTableName).
+		SetMap(map[string]interface{}{"message": "", "updated_at": time.Now().UTC()}).
+		Where(sq.And{sq.Eq{"twitter_user_id": userID}, sq.NotEq{"message": ""}}).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	return execRowsAffected(s.pr, query, args)
+}
@@ -0,0 +1,167 @@
+package mysql_test
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/mysql"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/suite"
+)
+
+type eraseDirectMessageSuite struct {
+	suite.Suite
+
+	db      *sql.DB
+	service model.EraseDirectMessageService
+}
+
+func TestEraseDirectMessageSuite(t *testing.T) {
+	suite.Run(t, new(eraseDirectMessageSuite))
+}
+
+func (s *eraseDirectMessageSuite) SetupSuite() {
+	db, err := openTestDB()
+	s.NoError(err)
+
+	s.db = db
+	s.service = mysql.NewEraseDirectMessageService(db)
+}
+
+func (s *eraseDirectMessageSuite) SetupTest() {
+	// Reset test db.
+	_, err := s.db.Exec("SET FOREIGN_KEY_CHECKS = 0")
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.EraseDirectMessageTableName))
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.TwitterUserTableName))
+	s.NoError(err)
+	_, err = s.db.Exec("SET FOREIGN_KEY_CHECKS = 1")
+	s.NoError(err)
+
+	// Create test twitter users.
+	tus := mysql.NewTwitterUserService(s.db)
+	for _, uid := range []uint64{1, 2, math.MaxUint64} {
+		tu := &model.TwitterUser{UserID: uid}
+		err = tus.InsertUpdate(tu)
+		s.NoError(err)
+	}
+}
+
+func (s *eraseDirectMessageSuite) TestAlreadyEraseDirectMessageIDs() {
+	userID := uint64(1)
+	cnt := 1000
+	ids := make([]uint64, cnt)
+	for i := 1; i <= cnt; i++ {
+		dummyID := math.MaxUint64 - uint64(i)
+		ids[i-1] = dummyID
+		edm := &model.EraseDirectMessage{TwitterDirectMessageID: dummyID, TwitterUserID: userID}
+		insertID, err := s.service.Insert(edm)
+		s.NoError(err)
+		s.Equal(uint64(i), insertID)
+	}
+
+	// Other user.
+	edm := &model.EraseDirectMessage{TwitterDirectMessageID: 10000, TwitterUserID: 2}
+	insertID, err := s.service.Insert(edm)
+	s.NoError(err)
+	s.Equal(uint64(cnt+1), insertID)
+
+	messageIDs, err := s.service.AlreadyEraseDirectMessageIDs(userID,
+		append(ids, []uint64{edm.TwitterDirectMessageID, 1, 2, 3}...))
+	s.NoError(err)
+	s.Len(messageIDs, cnt)
+
+	for _, id := range ids {
+		var isExist bool
+		for _, messageID := range messageIDs {
+			if messageID == id {
+				isExist = true
+				break
+			}
+		}
+
+		s.True(isExist)
+	}
+}
+
+func (s *eraseDirectMessageSuite) TestInsert() {
+	sentAt := time.Now().Add(-24 * time.Hour).UTC()
+	edm := &model.EraseDirectMessage{TwitterDirectMessageID: math.MaxUint64, ConversationID: "1-2",
+		SenderID: 1, RecipientID: 2, Message: "message", SentAt: sentAt, TwitterUserID: math.MaxUint64}
+	insertID, err := s.service.Insert(edm)
+	s.NoError(err)
+	s.Equal(uint64(1), insertID)
+
+	rows, err := sq.Select("*").
+		From(model.EraseDirectMessageTableName).RunWith(s.db).Query()
+	s.NoError(err)
+
+	var cnt int
+	for rows.Next() {
+		var actual model.EraseDirectMessage
+		err := rows.Scan(&actual.ID, &actual.TwitterDirectMessageID, &actual.ConversationID,
+			&actual.SenderID, &actual.RecipientID, &actual.Message, &actual.SentAt,
+			&actual.TwitterUserID, &actual.UpdatedAt, &actual.CreatedAt)
+		s.NoError(err)
+
+		s.Equal(insertID, actual.ID)
+		s.Equal(edm.TwitterDirectMessageID, actual.TwitterDirectMessageID)
+		s.Equal(edm.ConversationID, actual.ConversationID)
+		s.Equal(edm.SenderID, actual.SenderID)
+		s.Equal(edm.RecipientID, actual.RecipientID)
+		s.Equal(edm.Message, actual.Message)
+		s.WithinDuration(edm.SentAt.Truncate(time.Second), actual.SentAt, 0)
+		s.Equal(edm.TwitterUserID, actual.TwitterUserID)
+
+		threeSecAgo := time.Now().UTC().Add(-3 * time.Second)
+		s.True(actual.UpdatedAt.After(threeSecAgo))
+		s.True(actual.CreatedAt.After(threeSecAgo))
+
+		cnt++
+	}
+
+	s.Equal(1, cnt)
+	s.NoError(rows.Err())
+	s.NoError(rows.Close())
+
+	// Not exist user.
+	insertID, err = s.service.Insert(&model.EraseDirectMessage{TwitterUserID: 3})
+	s.Error(err)
+	s.Equal(uint64(0), insertID)
+}
+
+func (s *eraseDirectMessageSuite) TestScrubContent() {
+	for i, edm := range []*model.EraseDirectMessage{
+		{TwitterDirectMessageID: 1, Message: "message", TwitterUserID: 1},
+		{TwitterDirectMessageID: 2, TwitterUserID: 1},
+		{TwitterDirectMessageID: 3, Message: "other user", TwitterUserID: 2},
+	} {
+		insertID, err := s.service.Insert(edm)
+		s.NoError(err)
+		s.Equal(uint64(i+1), insertID)
+	}
+
+	cnt, err := s.service.ScrubContent(1)
+	s.NoError(err)
+	s.Equal(uint64(1), cnt)
+
+	var message string
+	err = sq.Select("message").From(model.EraseDirectMessageTableName).
+		Where(sq.Eq{"id": 1}).RunWith(s.db).QueryRow().Scan(&message)
+	s.NoError(err)
+	s.Equal("", message)
+
+	err = sq.Select("message").From(model.EraseDirectMessageTableName).
+		Where(sq.Eq{"id": 3}).RunWith(s.db).QueryRow().Scan(&message)
+	s.NoError(err)
+	s.Equal("other user", message)
+}
+
+func (s *eraseDirectMessageSuite) TearDownSuite() {
+	s.db.Close()
+}
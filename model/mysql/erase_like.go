@@ -0,0 +1,89 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	sq "github.com/Masterminds/squirrel"
+)
+
+// EraseLikeService is erase likes table service.
+type EraseLikeService struct {
+	pr prepareRunner
+}
+
+// NewEraseLikeService is create erase like service.
+func NewEraseLikeService(db *sql.DB) EraseLikeService {
+	return EraseLikeService{pr: newPrepareRunner(db)}
+}
+
+// AlreadyEraseLikeIDs return already erase like ids from argument ids.
+func (s EraseLikeService) AlreadyEraseLikeIDs(userID uint64, ids []uint64) ([]uint64, error) {
+	query, args, err := sq.Select("twitter_tweet_id").From(model.EraseLikeTableName).
+		Where(sq.Eq{"twitter_user_id": userID, "twitter_tweet_id": ids}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pr.Query(query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tweetIDs []uint64
+	for rows.Next() {
+		var id uint64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		tweetIDs = append(tweetIDs, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return tweetIDs, nil
+}
+
+// Insert is insert erase_likes table.
+func (s EraseLikeService) Insert(el *model.EraseLike) (uint64, error) {
+	now := time.Now().UTC()
+	query, args, err := sq.Insert(model.EraseLikeTableName).Columns(
+		"twitter_tweet_id", "tweet", "tweet_twitter_user_id", "twitter_user_id", "updated_at", "created_at").
+		Values(el.TwitterTweetID, el.Tweet, el.TweetTwitterUserID, el.TwitterUserID, now, now).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := s.pr.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	lastInsertID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastInsertID), nil
+}
+
+// ScrubContent empties the text of the unfavorited tweets, and returns the number of the scrubbed likes.
+func (s EraseLikeService) ScrubContent(userID uint64) (uint64, error) {
+	query, args, err := sq.Update(model.EraseLikeTableName).
+		SetMap(map[string]interface{}{"tweet": "", "updated_at": time.Now().UTC()}).
+		Where(sq.And{sq.Eq{"twitter_user_id": userID}, sq.NotEq{"tweet": ""}}).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	return execRowsAffected(s.pr, query, args)
+}
@@ -0,0 +1,80 @@
+package mysql
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	sq "github.com/Masterminds/squirrel"
+)
+
+// EraseLikeErrorService is erase like errors table service.
+type EraseLikeErrorService struct {
+	pr prepareRunner
+}
+
+// NewEraseLikeErrorService is create erase like error service.
+func NewEraseLikeErrorService(db *sql.DB) EraseLikeErrorService {
+	return EraseLikeErrorService{pr: newPrepareRunner(db)}
+}
+
+// LikeNotFoundIDs return not found like ids from argument ids.
+func (s EraseLikeErrorService) LikeNotFoundIDs(userID uint64, ids []uint64) ([]uint64, error) {
+	query, args, err := sq.Select("twitter_tweet_id").From(model.EraseLikeErrorTableName).
+		Where(sq.Eq{"tried_twitter_user_id": userID,
+			"status_code": http.StatusNotFound, "twitter_tweet_id": ids}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pr.Query(query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tweetIDs []uint64
+	for rows.Next() {
+		var id uint64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		tweetIDs = append(tweetIDs, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return tweetIDs, nil
+}
+
+// Insert is insert to erase like error table.
+func (s EraseLikeErrorService) Insert(ele *model.EraseLikeError) (uint64, error) {
+	now := time.Now().UTC()
+	query, args, err := sq.Insert(model.EraseLikeErrorTableName).Columns(
+		"tried_twitter_user_id", "twitter_tweet_id",
+		"status_code", "error_message", "updated_at", "created_at").
+		Values(ele.TriedTwitterUserID, ele.TwitterTweetID, ele.StatusCode, ele.ErrorMessage, now, now).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := s.pr.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	lastInsertID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastInsertID), nil
+}
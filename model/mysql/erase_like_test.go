@@ -0,0 +1,164 @@
+package mysql_test
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/mysql"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/suite"
+)
+
+type eraseLikeTestSuite struct {
+	suite.Suite
+
+	db      *sql.DB
+	service model.EraseLikeService
+}
+
+func TestEraseLikeSuite(t *testing.T) {
+	suite.Run(t, new(eraseLikeTestSuite))
+}
+
+func (s *eraseLikeTestSuite) SetupSuite() {
+	db, err := openTestDB()
+	s.NoError(err)
+
+	s.db = db
+	s.service = mysql.NewEraseLikeService(db)
+}
+
+func (s *eraseLikeTestSuite) SetupTest() {
+	// Reset test db.
+	_, err := s.db.Exec("SET FOREIGN_KEY_CHECKS = 0")
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.EraseLikeTableName))
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.TwitterUserTableName))
+	s.NoError(err)
+	_, err = s.db.Exec("SET FOREIGN_KEY_CHECKS = 1")
+	s.NoError(err)
+
+	// Create test twitter users.
+	tus := mysql.NewTwitterUserService(s.db)
+	for _, uid := range []uint64{1, 2, math.MaxUint64} {
+		tu := &model.TwitterUser{UserID: uid}
+		err = tus.InsertUpdate(tu)
+		s.NoError(err)
+	}
+}
+
+func (s *eraseLikeTestSuite) TestAlreadyEraseLikeIDs() {
+	userID := uint64(1)
+	cnt := 1000
+	ids := make([]uint64, cnt)
+	dummyIDs := make([]uint64, cnt)
+	for i := 1; i <= cnt; i++ {
+		dummyID := math.MaxUint64 - uint64(i)
+		ids[i-1] = dummyID
+		dummyIDs[i-1] = dummyID
+		el := &model.EraseLike{TwitterTweetID: dummyID, TwitterUserID: userID}
+		insertID, err := s.service.Insert(el)
+		s.NoError(err)
+		s.Equal(uint64(i), insertID)
+	}
+
+	// Other user.
+	el := &model.EraseLike{TwitterTweetID: 10000, TwitterUserID: 2}
+	insertID, err := s.service.Insert(el)
+	s.NoError(err)
+	s.Equal(uint64(cnt+1), insertID)
+
+	ids = append(ids, []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}...)
+	tweetIDs, err := s.service.AlreadyEraseLikeIDs(userID, ids)
+	s.NoError(err)
+	s.Len(tweetIDs, cnt)
+
+	for _, dummyID := range dummyIDs {
+		var isExist bool
+		for _, tweetID := range tweetIDs {
+			if tweetID == dummyID {
+				isExist = true
+				break
+			}
+		}
+
+		s.True(isExist)
+	}
+}
+
+func (s *eraseLikeTestSuite) TestInsert() {
+	el := &model.EraseLike{TwitterTweetID: math.MaxUint64,
+		Tweet: "tweet", TweetTwitterUserID: 2, TwitterUserID: math.MaxUint64}
+	insertID, err := s.service.Insert(el)
+	s.NoError(err)
+	s.Equal(uint64(1), insertID)
+
+	rows, err := sq.Select("*").
+		From(model.EraseLikeTableName).RunWith(s.db).Query()
+	s.NoError(err)
+
+	var cnt int
+	for rows.Next() {
+		var actual model.EraseLike
+		err := rows.Scan(&actual.ID, &actual.TwitterTweetID, &actual.Tweet,
+			&actual.TweetTwitterUserID, &actual.TwitterUserID, &actual.UpdatedAt, &actual.CreatedAt)
+		s.NoError(err)
+
+		s.Equal(insertID, actual.ID)
+		s.Equal(el.TwitterTweetID, actual.TwitterTweetID)
+		s.Equal(el.Tweet, actual.Tweet)
+		s.Equal(el.TweetTwitterUserID, actual.TweetTwitterUserID)
+		s.Equal(el.TwitterUserID, actual.TwitterUserID)
+
+		threeSecAgo := time.Now().UTC().Add(-3 * time.Second)
+		s.True(actual.UpdatedAt.After(threeSecAgo))
+		s.True(actual.CreatedAt.After(threeSecAgo))
+
+		cnt++
+	}
+
+	s.Equal(1, cnt)
+	s.NoError(rows.Err())
+	s.NoError(rows.Close())
+
+	// Not exist user.
+	insertID, err = s.service.Insert(&model.EraseLike{TwitterUserID: 3})
+	s.Error(err)
+	s.Equal(uint64(0), insertID)
+}
+
+func (s *eraseLikeTestSuite) TestScrubContent() {
+	for i, el := range []*model.EraseLike{
+		{TwitterTweetID: 1, Tweet: "tweet", TwitterUserID: 1},
+		{TwitterTweetID: 2, TwitterUserID: 1},
+		{TwitterTweetID: 3, Tweet: "other user", TwitterUserID: 2},
+	} {
+		insertID, err := s.service.Insert(el)
+		s.NoError(err)
+		s.Equal(uint64(i+1), insertID)
+	}
+
+	cnt, err := s.service.ScrubContent(1)
+	s.NoError(err)
+	s.Equal(uint64(1), cnt)
+
+	var tweet string
+	err = sq.Select("tweet").From(model.EraseLikeTableName).
+		Where(sq.Eq{"id": 1}).RunWith(s.db).QueryRow().Scan(&tweet)
+	s.NoError(err)
+	s.Equal("", tweet)
+
+	err = sq.Select("tweet").From(model.EraseLikeTableName).
+		Where(sq.Eq{"id": 3}).RunWith(s.db).QueryRow().Scan(&tweet)
+	s.NoError(err)
+	s.Equal("other user", tweet)
+}
+
+func (s *eraseLikeTestSuite) TearDownSuite() {
+	s.db.Close()
+}
@@ -0,0 +1,42 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	sq "github.com/Masterminds/squirrel"
+)
+
+// EraseListService is erase lists table service.
+type EraseListService struct {
+	pr prepareRunner
+}
+
+// NewEraseListService is create erase list service.
+func NewEraseListService(db *sql.DB) EraseListService {
+	return EraseListService{pr: newPrepareRunner(db)}
+}
+
+// Insert is insert erase_lists table.
+func (s EraseListService) Insert(el *model.EraseList) (uint64, error) {
+	now := time.Now().UTC()
+	query, args, err := sq.Insert(model.EraseListTableName).Columns(
+		"twitter_list_id", "name", "mode", "description", "member_count", "twitter_user_id", "updated_at", "created_at").
+		Values(el.TwitterListID, el.Name, el.Mode, el.Description, el.MemberCount, el.TwitterUserID, now, now).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := s.pr.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	lastInsertID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastInsertID), nil
+}
@@ -0,0 +1,100 @@
+package mysql_test
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/mysql"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/suite"
+)
+
+type eraseListSuite struct {
+	suite.Suite
+
+	db      *sql.DB
+	service model.EraseListService
+}
+
+func TestEraseListSuite(t *testing.T) {
+	suite.Run(t, new(eraseListSuite))
+}
+
+func (s *eraseListSuite) SetupSuite() {
+	db, err := openTestDB()
+	s.NoError(err)
+
+	s.db = db
+	s.service = mysql.NewEraseListService(db)
+}
+
+func (s *eraseListSuite) SetupTest() {
+	// Reset test db.
+	_, err := s.db.Exec("SET FOREIGN_KEY_CHECKS = 0")
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.EraseListTableName))
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.TwitterUserTableName))
+	s.NoError(err)
+	_, err = s.db.Exec("SET FOREIGN_KEY_CHECKS = 1")
+	s.NoError(err)
+
+	// Create test twitter users.
+	tus := mysql.NewTwitterUserService(s.db)
+	for _, uid := range []uint64{1, 2, math.MaxUint64} {
+		tu := &model.TwitterUser{UserID: uid}
+		err = tus.InsertUpdate(tu)
+		s.NoError(err)
+	}
+}
+
+func (s *eraseListSuite) TestInsert() {
+	el := &model.EraseList{TwitterListID: math.MaxUint64, Name: "name", Mode: "private",
+		Description: "description", MemberCount: 10, TwitterUserID: math.MaxUint64}
+	insertID, err := s.service.Insert(el)
+	s.NoError(err)
+	s.Equal(uint64(1), insertID)
+
+	rows, err := sq.Select("*").
+		From(model.EraseListTableName).RunWith(s.db).Query()
+	s.NoError(err)
+
+	var cnt int
+	for rows.Next() {
+		var actual model.EraseList
+		err := rows.Scan(&actual.ID, &actual.TwitterListID, &actual.Name, &actual.Mode,
+			&actual.Description, &actual.MemberCount, &actual.TwitterUserID, &actual.UpdatedAt, &actual.CreatedAt)
+		s.NoError(err)
+
+		s.Equal(insertID, actual.ID)
+		s.Equal(el.TwitterListID, actual.TwitterListID)
+		s.Equal(el.Name, actual.Name)
+		s.Equal(el.Mode, actual.Mode)
+		s.Equal(el.Description, actual.Description)
+		s.Equal(el.MemberCount, actual.MemberCount)
+		s.Equal(el.TwitterUserID, actual.TwitterUserID)
+
+		threeSecAgo := time.Now().UTC().Add(-3 * time.Second)
+		s.True(actual.UpdatedAt.After(threeSecAgo))
+		s.True(actual.CreatedAt.After(threeSecAgo))
+
+		cnt++
+	}
+
+	s.Equal(1, cnt)
+	s.NoError(rows.Err())
+	s.NoError(rows.Close())
+
+	// Not exist user.
+	insertID, err = s.service.Insert(&model.EraseList{TwitterUserID: 3})
+	s.Error(err)
+	s.Equal(uint64(0), insertID)
+}
+
+func (s *eraseListSuite) TearDownSuite() {
+	s.db.Close()
+}
@@ -0,0 +1,42 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	sq "github.com/Masterminds/squirrel"
+)
+
+// EraseSavedSearchService is erase saved searches table service.
+type EraseSavedSearchService struct {
+	pr prepareRunner
+}
+
+// NewEraseSavedSearchService is create erase saved search service.
+func NewEraseSavedSearchService(db *sql.DB) EraseSavedSearchService {
+	return EraseSavedSearchService{pr: newPrepareRunner(db)}
+}
+
+// Insert is insert erase_saved_searches table.
+func (s EraseSavedSearchService) Insert(ess *model.EraseSavedSearch) (uint64, error) {
+	now := time.Now().UTC()
+	query, args, err := sq.Insert(model.EraseSavedSearchTableName).Columns(
+		"twitter_saved_search_id", "query", "twitter_user_id", "updated_at", "created_at").
+		Values(ess.TwitterSavedSearchID, ess.Query, ess.TwitterUserID, now, now).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := s.pr.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	lastInsertID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastInsertID), nil
+}
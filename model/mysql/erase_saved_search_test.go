@@ -0,0 +1,97 @@
+package mysql_test
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/mysql"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/suite"
+)
+
+type eraseSavedSearchSuite struct {
+	suite.Suite
+
+	db      *sql.DB
+	service model.EraseSavedSearchService
+}
+
+func TestEraseSavedSearchSuite(t *testing.T) {
+	suite.Run(t, new(eraseSavedSearchSuite))
+}
+
+func (s *eraseSavedSearchSuite) SetupSuite() {
+	db, err := openTestDB()
+	s.NoError(err)
+
+	s.db = db
+	s.service = mysql.NewEraseSavedSearchService(db)
+}
+
+func (s *eraseSavedSearchSuite) SetupTest() {
+	// Reset test db.
+	_, err := s.db.Exec("SET FOREIGN_KEY_CHECKS = 0")
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.EraseSavedSearchTableName))
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.TwitterUserTableName))
+	s.NoError(err)
+	_, err = s.db.Exec("SET FOREIGN_KEY_CHECKS = 1")
+	s.NoError(err)
+
+	// Create test twitter users.
+	tus := mysql.NewTwitterUserService(s.db)
+	for _, uid := range []uint64{1, 2, math.MaxUint64} {
+		tu := &model.TwitterUser{UserID: uid}
+		err = tus.InsertUpdate(tu)
+		s.NoError(err)
+	}
+}
+
+func (s *eraseSavedSearchSuite) TestInsert() {
+	ess := &model.EraseSavedSearch{TwitterSavedSearchID: math.MaxUint64,
+		Query: "from:screen_name", TwitterUserID: math.MaxUint64}
+	insertID, err := s.service.Insert(ess)
+	s.NoError(err)
+	s.Equal(uint64(1), insertID)
+
+	rows, err := sq.Select("*").
+		From(model.EraseSavedSearchTableName).RunWith(s.db).Query()
+	s.NoError(err)
+
+	var cnt int
+	for rows.Next() {
+		var actual model.EraseSavedSearch
+		err := rows.Scan(&actual.ID, &actual.TwitterSavedSearchID, &actual.Query,
+			&actual.TwitterUserID, &actual.UpdatedAt, &actual.CreatedAt)
+		s.NoError(err)
+
+		s.Equal(insertID, actual.ID)
+		s.Equal(ess.TwitterSavedSearchID, actual.TwitterSavedSearchID)
+		s.Equal(ess.Query, actual.Query)
+		s.Equal(ess.TwitterUserID, actual.TwitterUserID)
+
+		threeSecAgo := time.Now().UTC().Add(-3 * time.Second)
+		s.True(actual.UpdatedAt.After(threeSecAgo))
+		s.True(actual.CreatedAt.After(threeSecAgo))
+
+		cnt++
+	}
+
+	s.Equal(1, cnt)
+	s.NoError(rows.Err())
+	s.NoError(rows.Close())
+
+	// Not exist user.
+	insertID, err = s.service.Insert(&model.EraseSavedSearch{TwitterUserID: 3})
+	s.Error(err)
+	s.Equal(uint64(0), insertID)
+}
+
+func (s *eraseSavedSearchSuite) TearDownSuite() {
+	s.db.Close()
+}
@@ -8,6 +8,22 @@ import (
 	sq "github.com/Masterminds/squirrel"
 )
 
+var eraseTweetColumns = []string{"run_id", "twitter_tweet_id", "tweet", "tweet_hash", "raw_json",
+	"media_paths", "posted_at", "twitter_user_id", "updated_at", "created_at"}
+
+// eraseTweetUpsertSuffix updates the existing tweet of the same user.
+// LAST_INSERT_ID(id) makes the last insert id the id of the updated row.
+const eraseTweetUpsertSuffix = "ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id), run_id = VALUES(run_id), " +
+	"tweet = VALUES(tweet), tweet_hash = VALUES(tweet_hash), raw_json = VALUES(raw_json), media_paths = VALUES(media_paths), " +
+	"posted_at = VALUES(posted_at), updated_at = VALUES(updated_at)"
+
+// The erase tweets keep the raw json, so one statement is limited by the rows and the content bytes
+// to stay under max_allowed_packet (4MB by default of MySQL 5.7).
+const (
+	eraseTweetBulkInsertRowCnt  = 100
+	eraseTweetBulkInsertByteCnt = 1 << 20
+)
+
 // EraseTweetService is mysql database service.
 type EraseTweetService struct {
 	pr prepareRunner
@@ -54,11 +70,13 @@ func (s EraseTweetService) AlreadyEraseTweetIDs(userID uint64, ids []uint64) ([]
 }
 
 // Insert is insert erase_tweets table.
+// If the tweet of the same user exists, it updates the row and returns the id of it.
 func (s EraseTweetService) Insert(et *model.EraseTweet) (uint64, error) {
 	now := time.Now().UTC()
-	query, args, err := sq.Insert(model.EraseTweetTableName).Columns(
-		"twitter_tweet_id", "tweet", "posted_at", "twitter_user_id", "updated_at", "created_at").
-		Values(et.TwitterTweetID, et.Tweet, et.PostedAt, et.TwitterUserID, now, now).ToSql()
+	query, args, err := sq.Insert(model.EraseTweetTableName).Columns(eraseTweetColumns...).
+		Values(nullableID(et.RunID), et.TwitterTweetID, et.Tweet, et.TweetHash, et.RawJSON,
+			et.MediaPaths, et.PostedAt, et.TwitterUserID, now, now).
+		Suffix(eraseTweetUpsertSuffix).ToSql()
 	if err != nil {
 		return 0, err
 	}
@@ -75,3 +93,120 @@ func (s EraseTweetService) Insert(et *model.EraseTweet) (uint64, error) {
 
 	return uint64(lastInsertID), nil
 }
+
+// BulkInsert inserts erase tweets with multi-row statements.
+func (s EraseTweetService) BulkInsert(ets []*model.EraseTweet) error {
+	ets = model.UniqueEraseTweets(ets)
+	for len(ets) > 0 {
+		cnt := eraseTweetBulkInsertCnt(ets)
+		if err := s.bulkInsert(ets[:cnt]); err != nil {
+			return err
+		}
+
+		ets = ets[cnt:]
+	}
+
+	return nil
+}
+
+// eraseTweetBulkInsertCnt returns the number of the erase tweets inserted by one statement.
+// At least one row is inserted even if it exceeds the content bytes.
+func eraseTweetBulkInsertCnt(ets []*model.EraseTweet) int {
+	var bytes int
+	for i, et := range ets {
+		bytes += len(et.Tweet) + len(et.TweetHash) + len(et.RawJSON) + len(et.MediaPaths)
+		if i > 0 && bytes > eraseTweetBulkInsertByteCnt {
+			return i
+		} else if i+1 == eraseTweetBulkInsertRowCnt {
+			return i + 1
+		}
+	}
+
+	return len(ets)
+}
+
+func (s EraseTweetService) bulkInsert(ets []*model.EraseTweet) error {
+	now := time.Now().UTC()
+	b := sq.Insert(model.EraseTweetTableName).Columns(eraseTweetColumns...)
+	for _, et := range ets {
+		b = b.Values(nullableID(et.RunID), et.TwitterTweetID, et.Tweet, et.TweetHash, et.RawJSON,
+			et.MediaPaths, et.PostedAt, et.TwitterUserID, now, now)
+	}
+
+	query, args, err := b.Suffix(eraseTweetUpsertSuffix).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.pr.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CountByPostedMonth counts the erased tweets of the user by the posted month.
+func (s EraseTweetService) CountByPostedMonth(userID uint64) ([]*model.KeyCount, error) {
+	return s.countBy("DATE_FORMAT(posted_at, '%Y-%m')", userID)
+}
+
+// CountBySource counts the erased tweets of the user by the source html of the raw json.
+// The tweets without raw json are counted as the empty source.
+func (s EraseTweetService) CountBySource(userID uint64) ([]*model.KeyCount, error) {
+	return s.countBy("COALESCE(JSON_UNQUOTE(JSON_EXTRACT(NULLIF(raw_json, ''), '$.source')), '')", userID)
+}
+
+func (s EraseTweetService) countBy(key string, userID uint64) ([]*model.KeyCount, error) {
+	query, args, err := sq.Select(key+" AS k", "COUNT(*)").From(model.EraseTweetTableName).
+		Where(sq.Eq{"twitter_user_id": userID}).GroupBy("k").OrderBy("k").ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	return queryKeyCounts(s.pr, query, args)
+}
+
+// EraseTweets returns the erased tweets of the user in order of the posted time.
+func (s EraseTweetService) EraseTweets(userID uint64) ([]*model.EraseTweet, error) {
+	query, args, err := sq.Select("id", "COALESCE(run_id, 0)", "twitter_tweet_id", "tweet", "tweet_hash", "raw_json", "media_paths",
+		"posted_at", "twitter_user_id", "updated_at", "created_at").From(model.EraseTweetTableName).
+		Where(sq.Eq{"twitter_user_id": userID}).OrderBy("posted_at", "twitter_tweet_id").ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pr.Query(query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ets []*model.EraseTweet
+	for rows.Next() {
+		var et model.EraseTweet
+		err := rows.Scan(&et.ID, &et.RunID, &et.TwitterTweetID, &et.Tweet, &et.TweetHash, &et.RawJSON, &et.MediaPaths,
+			&et.PostedAt, &et.TwitterUserID, &et.UpdatedAt, &et.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		ets = append(ets, &et)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return ets, nil
+}
+
+// ScrubContent updates the content of the erase tweets by the upsert.
+// InnoDB may keep the former content in the free space of the pages until OPTIMIZE TABLE rebuilds the table,
+// and the binary log and backups keep it until they are purged.
+func (s EraseTweetService) ScrubContent(ets []*model.EraseTweet) error {
+	return s.BulkInsert(ets)
+}
@@ -0,0 +1,177 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+// FollowingSnapshotService is following snapshots and followings table service.
+type FollowingSnapshotService struct {
+	preparer sq.Preparer
+	pr       prepareRunner
+}
+
+// NewFollowingSnapshotService is create following snapshot service.
+// When calling Insert, specify an object implementing `Begin() (*sql.Tx, error)` (e.g. *sql.DB) as an argument.
+func NewFollowingSnapshotService(preparer sq.Preparer) FollowingSnapshotService {
+	return FollowingSnapshotService{preparer: preparer, pr: newPrepareRunner(preparer)}
+}
+
+// Insert inserts the snapshot and its followings in a transaction.
+func (s FollowingSnapshotService) Insert(fs *model.FollowingSnapshot, followingUserIDs []uint64) (insertID uint64, err error) {
+	// Begin transaction.
+	beginner, ok := s.preparer.(beginner)
+	if !ok {
+		return 0, errors.New("preparer has no method Begin")
+	}
+
+	tx, err := beginner.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	// Rollback.
+	defer func() {
+		if err == nil {
+			err = tx.Commit()
+		} else if rErr := tx.Rollback(); rErr != nil {
+			err = errors.Wrap(err, rErr.Error())
+		}
+
+		if err != nil {
+			insertID = 0
+		}
+	}()
+
+	txService := NewFollowingSnapshotService(tx)
+
+	insertID, err = txService.insertSnapshot(fs)
+	if err != nil {
+		return 0, err
+	}
+
+	for len(followingUserIDs) > 0 {
+		cnt := bulkInsertRowCnt
+		if len(followingUserIDs) < cnt {
+			cnt = len(followingUserIDs)
+		}
+
+		if err := txService.bulkInsertFollowings(insertID, followingUserIDs[:cnt]); err != nil {
+			return 0, err
+		}
+
+		followingUserIDs = followingUserIDs[cnt:]
+	}
+
+	return insertID, nil
+}
+
+func (s FollowingSnapshotService) insertSnapshot(fs *model.FollowingSnapshot) (uint64, error) {
+	now := time.Now().UTC()
+	query, args, err := sq.Insert(model.FollowingSnapshotTableName).Columns(
+		"twitter_user_id", "updated_at", "created_at").
+		Values(fs.TwitterUserID, now, now).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := s.pr.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	lastInsertID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastInsertID), nil
+}
+
+func (s FollowingSnapshotService) bulkInsertFollowings(snapshotID uint64, followingUserIDs []uint64) error {
+	now := time.Now().UTC()
+	b := sq.Insert(model.FollowingTableName).Columns(
+		"following_snapshot_id", "following_twitter_user_id", "unfollowed", "updated_at", "created_at")
+	for _, id := range followingUserIDs {
+		b = b.Values(snapshotID, id, false, now, now)
+	}
+
+	query, args, err := b.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.pr.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Followings returns the followings of the user's snapshot.
+func (s FollowingSnapshotService) Followings(userID, snapshotID uint64) ([]*model.Following, error) {
+	query, args, err := sq.Select("f.*").From(model.FollowingTableName + " f").
+		Join(model.FollowingSnapshotTableName + " fs ON fs.id = f.following_snapshot_id").
+		Where(sq.Eq{"fs.id": snapshotID, "fs.twitter_user_id": userID}).OrderBy("f.id").ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pr.Query(query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fs []*model.Following
+	for rows.Next() {
+		f := &model.Following{}
+		err := rows.Scan(&f.ID, &f.FollowingSnapshotID, &f.FollowingTwitterUserID,
+			&f.Unfollowed, &f.UpdatedAt, &f.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		fs = append(fs, f)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return fs, nil
+}
+
+// UpdateUnfollowed marks the following of the snapshot as unfollowed.
+func (s FollowingSnapshotService) UpdateUnfollowed(snapshotID, followingUserID uint64) error {
+	setMap := map[string]interface{}{"unfollowed": true, "updated_at": time.Now().UTC()}
+	query, args, err := sq.Update(model.FollowingTableName).SetMap(setMap).
+		Where(sq.Eq{"following_snapshot_id": snapshotID, "following_twitter_user_id": followingUserID}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	res, err := s.pr.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	updateCnt, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if updateCnt < 1 {
+		return errors.Errorf("row not found: %d, %d", snapshotID, followingUserID)
+	}
+
+	return nil
+}
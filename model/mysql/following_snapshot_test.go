@@ -0,0 +1,107 @@
+package mysql_test
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/mysql"
+	"github.com/stretchr/testify/suite"
+)
+
+type followingSnapshotSuite struct {
+	suite.Suite
+
+	db      *sql.DB
+	service model.FollowingSnapshotService
+}
+
+func TestFollowingSnapshotSuite(t *testing.T) {
+	suite.Run(t, new(followingSnapshotSuite))
+}
+
+func (s *followingSnapshotSuite) SetupSuite() {
+	db, err := openTestDB()
+	s.NoError(err)
+
+	s.db = db
+	s.service = mysql.NewFollowingSnapshotService(db)
+}
+
+func (s *followingSnapshotSuite) SetupTest() {
+	// Reset test db.
+	_, err := s.db.Exec("SET FOREIGN_KEY_CHECKS = 0")
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.FollowingTableName))
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.FollowingSnapshotTableName))
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.TwitterUserTableName))
+	s.NoError(err)
+	_, err = s.db.Exec("SET FOREIGN_KEY_CHECKS = 1")
+	s.NoError(err)
+
+	// Create test twitter users.
+	tus := mysql.NewTwitterUserService(s.db)
+	for _, uid := range []uint64{1, 2, 3, math.MaxUint64} {
+		tu := &model.TwitterUser{UserID: uid}
+		err = tus.InsertUpdate(tu)
+		s.NoError(err)
+	}
+}
+
+func (s *followingSnapshotSuite) TestInsert() {
+	userID := uint64(math.MaxUint64)
+	insertID, err := s.service.Insert(&model.FollowingSnapshot{TwitterUserID: userID}, []uint64{1, 2, 3})
+	s.NoError(err)
+	s.Equal(uint64(1), insertID)
+
+	fs, err := s.service.Followings(userID, insertID)
+	s.NoError(err)
+	s.Len(fs, 3)
+	for i, f := range fs {
+		s.Equal(insertID, f.FollowingSnapshotID)
+		s.Equal(uint64(i+1), f.FollowingTwitterUserID)
+		s.False(f.Unfollowed)
+	}
+
+	// Other user.
+	fs, err = s.service.Followings(1, insertID)
+	s.NoError(err)
+	s.Len(fs, 0)
+
+	// Not exist following user. Rollback snapshot.
+	insertID, err = s.service.Insert(&model.FollowingSnapshot{TwitterUserID: userID}, []uint64{1, 4})
+	s.Error(err)
+	s.Equal(uint64(0), insertID)
+
+	var cnt int
+	err = s.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", model.FollowingSnapshotTableName)).Scan(&cnt)
+	s.NoError(err)
+	s.Equal(1, cnt)
+}
+
+func (s *followingSnapshotSuite) TestUpdateUnfollowed() {
+	userID := uint64(math.MaxUint64)
+	snapshotID, err := s.service.Insert(&model.FollowingSnapshot{TwitterUserID: userID}, []uint64{1, 2})
+	s.NoError(err)
+
+	err = s.service.UpdateUnfollowed(snapshotID, 2)
+	s.NoError(err)
+
+	fs, err := s.service.Followings(userID, snapshotID)
+	s.NoError(err)
+	s.Len(fs, 2)
+	s.False(fs[0].Unfollowed)
+	s.True(fs[1].Unfollowed)
+
+	// Not exist following.
+	err = s.service.UpdateUnfollowed(snapshotID, 3)
+	s.Error(err)
+}
+
+func (s *followingSnapshotSuite) TearDownSuite() {
+	s.db.Close()
+}
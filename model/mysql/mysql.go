@@ -1,29 +1,148 @@
 package mysql
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
+	"io/ioutil"
 
+	"github.com/178inaba/tweeraser/config"
+	"github.com/178inaba/tweeraser/model"
 	sq "github.com/Masterminds/squirrel"
 	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
 )
 
 type beginner interface {
 	Begin() (*sql.Tx, error)
 }
 
-// Open is open mysql connection.
-func Open(user, addr, dbName string) (*sql.DB, error) {
+// tlsConfigName is the registered tls config name of the certificate files.
+const tlsConfigName = "tweeraser"
+
+// Open is open mysql connection from [database] config.
+func Open(conf config.Database) (*sql.DB, error) {
+	if conf.DSN != "" {
+		return OpenDSN(conf.DSN)
+	}
+
+	password, err := conf.ReadPassword()
+	if err != nil {
+		return nil, err
+	}
+
 	c := &mysql.Config{
-		User:      user,
+		User:      conf.User,
+		Passwd:    password,
 		Net:       "tcp",
-		Addr:      addr,
-		DBName:    dbName,
-		ParseTime: true,
+		Addr:      conf.Host,
+		DBName:    conf.Name,
+		TLSConfig: conf.TLS,
+	}
+
+	if conf.TLSCAFile != "" || conf.TLSCertFile != "" {
+		if err := registerTLSConfig(conf); err != nil {
+			return nil, err
+		}
+
+		c.TLSConfig = tlsConfigName
+	}
+
+	return OpenDSN(c.FormatDSN())
+}
+
+// OpenDSN is open mysql connection of the dsn.
+// parseTime and utf8mb4 collation are always enabled.
+func OpenDSN(dsn string) (*sql.DB, error) {
+	c, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
 	}
 
+	c.Collation = "utf8mb4_general_ci"
+	c.ParseTime = true
 	return sql.Open("mysql", c.FormatDSN())
 }
 
+func registerTLSConfig(conf config.Database) error {
+	tc := &tls.Config{InsecureSkipVerify: conf.TLS == "skip-verify"}
+	if conf.TLSCAFile != "" {
+		pem, err := ioutil.ReadFile(conf.TLSCAFile)
+		if err != nil {
+			return err
+		}
+
+		tc.RootCAs = x509.NewCertPool()
+		if !tc.RootCAs.AppendCertsFromPEM(pem) {
+			return errors.Errorf("Fail append ca certificate of %s.", conf.TLSCAFile)
+		}
+	}
+
+	if conf.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(conf.TLSCertFile, conf.TLSKeyFile)
+		if err != nil {
+			return err
+		}
+
+		tc.Certificates = []tls.Certificate{cert}
+	}
+
+	return mysql.RegisterTLSConfig(tlsConfigName, tc)
+}
+
+// nullableID returns nil for the id 0 to store NULL into the nullable foreign key.
+func nullableID(id uint64) interface{} {
+	if id == 0 {
+		return nil
+	}
+
+	return id
+}
+
+// queryKeyCounts runs the query that selects the key and the count of the group.
+func queryKeyCounts(pr prepareRunner, query string, args []interface{}) ([]*model.KeyCount, error) {
+	rows, err := pr.Query(query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var kcs []*model.KeyCount
+	for rows.Next() {
+		var kc model.KeyCount
+		err := rows.Scan(&kc.Key, &kc.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		kcs = append(kcs, &kc)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return kcs, nil
+}
+
+// execRowsAffected runs the query and returns the number of the affected rows.
+func execRowsAffected(pr prepareRunner, query string, args []interface{}) (uint64, error) {
+	res, err := pr.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(cnt), nil
+}
+
 type prepareRunner struct {
 	preparer sq.Preparer
 	canClose bool
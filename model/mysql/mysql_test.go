@@ -1,14 +1,69 @@
 package mysql_test
 
 import (
+	"database/sql"
+	"fmt"
+	"os"
 	"testing"
 
+	"github.com/178inaba/tweeraser/config"
 	"github.com/178inaba/tweeraser/model/mysql"
 	"github.com/stretchr/testify/assert"
 )
 
+// testDSN returns TWEERASER_TEST_DSN environment variable or the local root test db.
+func testDSN() string {
+	if dsn := os.Getenv("TWEERASER_TEST_DSN"); dsn != "" {
+		return dsn
+	}
+
+	return "root@tcp(127.0.0.1:3306)/tweeraser_test"
+}
+
+func openTestDB() (*sql.DB, error) {
+	return mysql.OpenDSN(testDSN())
+}
+
+func TestMain(m *testing.M) {
+	// Migrate test db to the latest before all suites.
+	db, err := openTestDB()
+	if err == nil {
+		_, err = mysql.NewSchemaMigrationService(db).Up()
+		db.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Fail migrate test db: %s\n", err)
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestOpen(t *testing.T) {
+	db, err := mysql.Open(config.Database{DSN: testDSN()})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Ping())
+	db.Close()
+
+	// Not exist password file.
+	db, err = mysql.Open(config.Database{User: "root", PasswordFile: "path/nothing"})
+	assert.Error(t, err)
+	assert.Nil(t, db)
+
+	// Not exist ca file.
+	db, err = mysql.Open(config.Database{User: "root", TLS: "true", TLSCAFile: "path/nothing"})
+	assert.Error(t, err)
+	assert.Nil(t, db)
+}
+
+func TestOpenDSN(t *testing.T) {
+	db, err := mysql.OpenDSN("invalid dsn")
+	assert.Error(t, err)
+	assert.Nil(t, db)
+}
+
 func TestSetMaxOpenConnsFromDB(t *testing.T) {
-	db, err := mysql.Open("root", "", "tweeraser_test")
+	db, err := openTestDB()
 	assert.NoError(t, err)
 	defer db.Close()
 
@@ -0,0 +1,64 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// QueryService is user written query service.
+type QueryService struct {
+	db *sql.DB
+}
+
+// NewQueryService is create query service.
+func NewQueryService(db *sql.DB) QueryService {
+	return QueryService{db: db}
+}
+
+// QueryTweetIDs runs the query in read only transaction and returns tweet ids.
+// The query must select exactly one column of tweet id.
+func (s QueryService) QueryTweetIDs(query string) (ids []uint64, err error) {
+	tx, err := s.db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	// Read only. Always rollback.
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = rErr
+		}
+	}()
+
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	} else if len(columns) != 1 {
+		return nil, errors.Errorf("query must select one column, but selected %d columns", len(columns))
+	}
+
+	for rows.Next() {
+		var id uint64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
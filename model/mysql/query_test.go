@@ -0,0 +1,89 @@
+package mysql_test
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/mysql"
+	"github.com/stretchr/testify/suite"
+)
+
+type querySuite struct {
+	suite.Suite
+
+	db      *sql.DB
+	service model.QueryService
+}
+
+func TestQuerySuite(t *testing.T) {
+	suite.Run(t, new(querySuite))
+}
+
+func (s *querySuite) SetupSuite() {
+	db, err := openTestDB()
+	s.NoError(err)
+
+	s.db = db
+	s.service = mysql.NewQueryService(db)
+}
+
+func (s *querySuite) SetupTest() {
+	// Reset test db.
+	_, err := s.db.Exec("SET FOREIGN_KEY_CHECKS = 0")
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.ArchivedTweetTableName))
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.TwitterUserTableName))
+	s.NoError(err)
+	_, err = s.db.Exec("SET FOREIGN_KEY_CHECKS = 1")
+	s.NoError(err)
+
+	// Create test twitter users.
+	tus := mysql.NewTwitterUserService(s.db)
+	for _, uid := range []uint64{1, 2, math.MaxUint64} {
+		tu := &model.TwitterUser{UserID: uid}
+		err = tus.InsertUpdate(tu)
+		s.NoError(err)
+	}
+}
+
+func (s *querySuite) TestQueryTweetIDs() {
+	now := time.Now().UTC()
+	ats := []*model.ArchivedTweet{
+		{TwitterUserID: 1, TwitterTweetID: math.MaxUint64, Tweet: "foo", PostedAt: now},
+		{TwitterUserID: 1, TwitterTweetID: 100, Tweet: "bar", PostedAt: now},
+		{TwitterUserID: 2, TwitterTweetID: 200, Tweet: "foo", PostedAt: now},
+	}
+	err := mysql.NewArchivedTweetService(s.db).BulkInsertUpdate(ats)
+	s.NoError(err)
+
+	ids, err := s.service.QueryTweetIDs(fmt.Sprintf(
+		"SELECT twitter_tweet_id FROM %s WHERE twitter_user_id = 1 AND tweet = 'foo'", model.ArchivedTweetTableName))
+	s.NoError(err)
+	s.Equal([]uint64{math.MaxUint64}, ids)
+
+	// Not found.
+	ids, err = s.service.QueryTweetIDs(fmt.Sprintf(
+		"SELECT twitter_tweet_id FROM %s WHERE tweet = 'baz'", model.ArchivedTweetTableName))
+	s.NoError(err)
+	s.Len(ids, 0)
+
+	// Multiple columns.
+	ids, err = s.service.QueryTweetIDs(fmt.Sprintf(
+		"SELECT twitter_tweet_id, tweet FROM %s", model.ArchivedTweetTableName))
+	s.Error(err)
+	s.Nil(ids)
+
+	// Read only.
+	ids, err = s.service.QueryTweetIDs(fmt.Sprintf("DELETE FROM %s", model.ArchivedTweetTableName))
+	s.Error(err)
+	s.Nil(ids)
+}
+
+func (s *querySuite) TearDownSuite() {
+	s.db.Close()
+}
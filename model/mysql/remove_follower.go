@@ -0,0 +1,115 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+// RemoveFollowerService is remove followers table service.
+type RemoveFollowerService struct {
+	pr prepareRunner
+}
+
+// NewRemoveFollowerService is create remove follower service.
+func NewRemoveFollowerService(db *sql.DB) RemoveFollowerService {
+	return RemoveFollowerService{pr: newPrepareRunner(db)}
+}
+
+// PendingUnblocks returns the removed followers of the user which are blocked but not unblocked yet.
+func (s RemoveFollowerService) PendingUnblocks(userID uint64) ([]*model.RemoveFollower, error) {
+	query, args, err := sq.Select("*").From(model.RemoveFollowerTableName).
+		Where(sq.Eq{"twitter_user_id": userID, "status": model.RemoveFollowerStatusPendingUnblock}).
+		OrderBy("id").ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pr.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rfs []*model.RemoveFollower
+	for rows.Next() {
+		rf := &model.RemoveFollower{}
+		err := rows.Scan(&rf.ID, &rf.TwitterUserID, &rf.FollowerTwitterUserID,
+			&rf.FollowerScreenName, &rf.Status, &rf.UpdatedAt, &rf.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		rfs = append(rfs, rf)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return rfs, nil
+}
+
+// Insert is insert remove_followers table.
+func (s RemoveFollowerService) Insert(rf *model.RemoveFollower) (uint64, error) {
+	now := time.Now().UTC()
+	query, args, err := sq.Insert(model.RemoveFollowerTableName).Columns(
+		"twitter_user_id", "follower_twitter_user_id", "follower_screen_name", "status", "updated_at", "created_at").
+		Values(rf.TwitterUserID, rf.FollowerTwitterUserID, rf.FollowerScreenName, rf.Status, now, now).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := s.pr.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	lastInsertID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastInsertID), nil
+}
+
+// UpdateStatus updates the status of the removed follower.
+func (s RemoveFollowerService) UpdateStatus(id uint64, status string) error {
+	query, args, err := sq.Update(model.RemoveFollowerTableName).
+		SetMap(map[string]interface{}{"status": status, "updated_at": time.Now().UTC()}).
+		Where(sq.Eq{"id": id}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	res, err := s.pr.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	updateCnt, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if updateCnt < 1 {
+		return errors.Errorf("row not found: %d", id)
+	}
+
+	return nil
+}
+
+// Delete deletes the removed follower. It is used when the block fails, so the follower is not blocked.
+func (s RemoveFollowerService) Delete(id uint64) error {
+	query, args, err := sq.Delete(model.RemoveFollowerTableName).Where(sq.Eq{"id": id}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.pr.Exec(query, args...)
+	return err
+}
@@ -0,0 +1,155 @@
+package mysql_test
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/mysql"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/suite"
+)
+
+type removeFollowerSuite struct {
+	suite.Suite
+
+	db      *sql.DB
+	service model.RemoveFollowerService
+}
+
+func TestRemoveFollowerSuite(t *testing.T) {
+	suite.Run(t, new(removeFollowerSuite))
+}
+
+func (s *removeFollowerSuite) SetupSuite() {
+	db, err := openTestDB()
+	s.NoError(err)
+
+	s.db = db
+	s.service = mysql.NewRemoveFollowerService(db)
+}
+
+func (s *removeFollowerSuite) SetupTest() {
+	// Reset test db.
+	_, err := s.db.Exec("SET FOREIGN_KEY_CHECKS = 0")
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.RemoveFollowerTableName))
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.TwitterUserTableName))
+	s.NoError(err)
+	_, err = s.db.Exec("SET FOREIGN_KEY_CHECKS = 1")
+	s.NoError(err)
+
+	// Create test twitter users.
+	tus := mysql.NewTwitterUserService(s.db)
+	for _, uid := range []uint64{1, 2, math.MaxUint64} {
+		tu := &model.TwitterUser{UserID: uid}
+		err = tus.InsertUpdate(tu)
+		s.NoError(err)
+	}
+}
+
+func (s *removeFollowerSuite) TestPendingUnblocks() {
+	userID := uint64(1)
+	for i, status := range []string{model.RemoveFollowerStatusPendingUnblock,
+		model.RemoveFollowerStatusRemoved, model.RemoveFollowerStatusPendingUnblock} {
+		insertID, err := s.service.Insert(&model.RemoveFollower{TwitterUserID: userID,
+			FollowerTwitterUserID: uint64(i+1) * 10, Status: status})
+		s.NoError(err)
+		s.Equal(uint64(i+1), insertID)
+	}
+
+	// Other user.
+	insertID, err := s.service.Insert(&model.RemoveFollower{TwitterUserID: 2,
+		FollowerTwitterUserID: 40, Status: model.RemoveFollowerStatusPendingUnblock})
+	s.NoError(err)
+	s.Equal(uint64(4), insertID)
+
+	rfs, err := s.service.PendingUnblocks(userID)
+	s.NoError(err)
+	s.Len(rfs, 2)
+	s.Equal(uint64(1), rfs[0].ID)
+	s.Equal(uint64(10), rfs[0].FollowerTwitterUserID)
+	s.Equal(model.RemoveFollowerStatusPendingUnblock, rfs[0].Status)
+	s.Equal(uint64(3), rfs[1].ID)
+	s.Equal(uint64(30), rfs[1].FollowerTwitterUserID)
+}
+
+func (s *removeFollowerSuite) TestUpdateStatus() {
+	insertID, err := s.service.Insert(&model.RemoveFollower{TwitterUserID: 1,
+		FollowerTwitterUserID: 10, Status: model.RemoveFollowerStatusPendingUnblock})
+	s.NoError(err)
+
+	err = s.service.UpdateStatus(insertID, model.RemoveFollowerStatusRemoved)
+	s.NoError(err)
+
+	rfs, err := s.service.PendingUnblocks(1)
+	s.NoError(err)
+	s.Len(rfs, 0)
+
+	// Not exist row.
+	err = s.service.UpdateStatus(insertID+1, model.RemoveFollowerStatusRemoved)
+	s.Error(err)
+}
+
+func (s *removeFollowerSuite) TestDelete() {
+	insertID, err := s.service.Insert(&model.RemoveFollower{TwitterUserID: 1,
+		FollowerTwitterUserID: 10, Status: model.RemoveFollowerStatusPendingUnblock})
+	s.NoError(err)
+
+	err = s.service.Delete(insertID)
+	s.NoError(err)
+
+	rfs, err := s.service.PendingUnblocks(1)
+	s.NoError(err)
+	s.Len(rfs, 0)
+}
+
+func (s *removeFollowerSuite) TestInsert() {
+	rf := &model.RemoveFollower{TwitterUserID: math.MaxUint64,
+		FollowerTwitterUserID: math.MaxUint64, FollowerScreenName: "screen_name",
+		Status: model.RemoveFollowerStatusRemoved}
+	insertID, err := s.service.Insert(rf)
+	s.NoError(err)
+	s.Equal(uint64(1), insertID)
+
+	rows, err := sq.Select("*").
+		From(model.RemoveFollowerTableName).RunWith(s.db).Query()
+	s.NoError(err)
+
+	var cnt int
+	for rows.Next() {
+		var actual model.RemoveFollower
+		err := rows.Scan(&actual.ID, &actual.TwitterUserID, &actual.FollowerTwitterUserID,
+			&actual.FollowerScreenName, &actual.Status, &actual.UpdatedAt, &actual.CreatedAt)
+		s.NoError(err)
+
+		s.Equal(insertID, actual.ID)
+		s.Equal(rf.TwitterUserID, actual.TwitterUserID)
+		s.Equal(rf.FollowerTwitterUserID, actual.FollowerTwitterUserID)
+		s.Equal(rf.FollowerScreenName, actual.FollowerScreenName)
+		s.Equal(rf.Status, actual.Status)
+
+		threeSecAgo := time.Now().UTC().Add(-3 * time.Second)
+		s.True(actual.UpdatedAt.After(threeSecAgo))
+		s.True(actual.CreatedAt.After(threeSecAgo))
+
+		cnt++
+	}
+
+	s.Equal(1, cnt)
+	s.NoError(rows.Err())
+	s.NoError(rows.Close())
+
+	// Not exist user.
+	insertID, err = s.service.Insert(&model.RemoveFollower{TwitterUserID: 3})
+	s.Error(err)
+	s.Equal(uint64(0), insertID)
+}
+
+func (s *removeFollowerSuite) TearDownSuite() {
+	s.db.Close()
+}
@@ -0,0 +1,517 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+// errNoSuchTable is the mysql error number of the table does not exist.
+const errNoSuchTable = 1146
+
+// migration is one version of the schema.
+// Each statement is executed in order, so up and down must not depend on multi statements.
+type migration struct {
+	version uint64
+	name    string
+	up      []string
+	down    []string
+}
+
+// migrations must be appended with the next version. Never edit the applied migration.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create_tables",
+		// IF NOT EXISTS adopts the database created by the old ddl.sql.
+		up: []string{
+			`CREATE TABLE IF NOT EXISTS twitter_users (
+			  user_id BIGINT UNSIGNED NOT NULL,
+			  screen_name VARCHAR(15) NOT NULL,
+			  name VARCHAR(20) NOT NULL,
+			  lang VARCHAR(100) NOT NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL,
+			  PRIMARY KEY (user_id)
+			) ENGINE InnoDB CHARSET utf8`,
+			`CREATE TABLE IF NOT EXISTS erase_tweets (
+			  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+			  twitter_tweet_id BIGINT UNSIGNED NOT NULL,
+			  tweet VARCHAR(140) NOT NULL,
+			  posted_at DATETIME NOT NULL,
+			  twitter_user_id BIGINT UNSIGNED NOT NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL,
+			  PRIMARY KEY (id),
+			  FOREIGN KEY (twitter_user_id) REFERENCES twitter_users (user_id)
+			) ENGINE InnoDB CHARSET utf8`,
+			`CREATE TABLE IF NOT EXISTS erase_errors (
+			  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+			  tried_twitter_user_id BIGINT UNSIGNED NOT NULL,
+			  twitter_tweet_id BIGINT UNSIGNED NOT NULL,
+			  status_code SMALLINT(3) UNSIGNED NOT NULL,
+			  error_message TEXT NOT NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL,
+			  PRIMARY KEY (id)
+			) ENGINE InnoDB CHARSET utf8`,
+		},
+		down: []string{
+			`DROP TABLE erase_errors`,
+			`DROP TABLE erase_tweets`,
+			`DROP TABLE twitter_users`,
+		},
+	},
+	{
+		version: 2,
+		name:    "convert_to_utf8mb4",
+		up: []string{
+			`ALTER DATABASE CHARACTER SET utf8mb4`,
+			`ALTER TABLE twitter_users CONVERT TO CHARACTER SET utf8mb4`,
+			`ALTER TABLE twitter_users MODIFY name VARCHAR(50) NOT NULL`,
+			`ALTER TABLE erase_tweets CONVERT TO CHARACTER SET utf8mb4`,
+			`ALTER TABLE erase_tweets MODIFY tweet TEXT NOT NULL`,
+			`ALTER TABLE erase_errors CONVERT TO CHARACTER SET utf8mb4`,
+			`ALTER TABLE erase_errors MODIFY error_message TEXT NOT NULL`,
+		},
+		down: []string{
+			`ALTER TABLE erase_errors CONVERT TO CHARACTER SET utf8`,
+			`ALTER TABLE erase_errors MODIFY error_message TEXT NOT NULL`,
+			`ALTER TABLE erase_tweets CONVERT TO CHARACTER SET utf8`,
+			`ALTER TABLE erase_tweets MODIFY tweet VARCHAR(140) NOT NULL`,
+			`ALTER TABLE twitter_users CONVERT TO CHARACTER SET utf8`,
+			`ALTER TABLE twitter_users MODIFY name VARCHAR(20) NOT NULL`,
+			`ALTER DATABASE CHARACTER SET utf8`,
+		},
+	},
+	{
+		version: 3,
+		name:    "add_erase_tweets_raw_json_and_media_paths",
+		up: []string{
+			`ALTER TABLE erase_tweets ADD raw_json MEDIUMTEXT NOT NULL AFTER tweet, ADD media_paths TEXT NOT NULL AFTER raw_json`,
+		},
+		down: []string{
+			`ALTER TABLE erase_tweets DROP raw_json, DROP media_paths`,
+		},
+	},
+	{
+		version: 4,
+		name:    "create_archive_and_wipe_tables",
+		up: []string{
+			`CREATE TABLE archived_tweets (
+			  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+			  twitter_user_id BIGINT UNSIGNED NOT NULL,
+			  twitter_tweet_id BIGINT UNSIGNED NOT NULL,
+			  in_reply_to_status_id BIGINT UNSIGNED NOT NULL,
+			  in_reply_to_user_id BIGINT UNSIGNED NOT NULL,
+			  retweeted_status_id BIGINT UNSIGNED NOT NULL,
+			  retweeted_status_user_id BIGINT UNSIGNED NOT NULL,
+			  source VARCHAR(255) NOT NULL,
+			  tweet TEXT NOT NULL,
+			  expanded_urls TEXT NOT NULL,
+			  posted_at DATETIME NOT NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL,
+			  PRIMARY KEY (id),
+			  UNIQUE KEY (twitter_user_id, twitter_tweet_id),
+			  FOREIGN KEY (twitter_user_id) REFERENCES twitter_users (user_id)
+			) ENGINE InnoDB CHARSET utf8mb4`,
+			`CREATE TABLE erase_likes (
+			  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+			  twitter_tweet_id BIGINT UNSIGNED NOT NULL,
+			  tweet TEXT NOT NULL,
+			  tweet_twitter_user_id BIGINT UNSIGNED NOT NULL,
+			  twitter_user_id BIGINT UNSIGNED NOT NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL,
+			  PRIMARY KEY (id),
+			  FOREIGN KEY (twitter_user_id) REFERENCES twitter_users (user_id)
+			) ENGINE InnoDB CHARSET utf8mb4`,
+			`CREATE TABLE erase_like_errors (
+			  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+			  tried_twitter_user_id BIGINT UNSIGNED NOT NULL,
+			  twitter_tweet_id BIGINT UNSIGNED NOT NULL,
+			  status_code SMALLINT(3) UNSIGNED NOT NULL,
+			  error_message TEXT NOT NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL,
+			  PRIMARY KEY (id)
+			) ENGINE InnoDB CHARSET utf8mb4`,
+			`CREATE TABLE erase_direct_messages (
+			  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+			  twitter_direct_message_id BIGINT UNSIGNED NOT NULL,
+			  conversation_id VARCHAR(64) NOT NULL,
+			  sender_id BIGINT UNSIGNED NOT NULL,
+			  recipient_id BIGINT UNSIGNED NOT NULL,
+			  message TEXT NOT NULL,
+			  sent_at DATETIME NOT NULL,
+			  twitter_user_id BIGINT UNSIGNED NOT NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL,
+			  PRIMARY KEY (id),
+			  FOREIGN KEY (twitter_user_id) REFERENCES twitter_users (user_id)
+			) ENGINE InnoDB CHARSET utf8mb4`,
+			`CREATE TABLE wipe_jobs (
+			  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+			  twitter_user_id BIGINT UNSIGNED NOT NULL,
+			  completed_stages VARCHAR(255) NOT NULL,
+			  finished TINYINT(1) NOT NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL,
+			  PRIMARY KEY (id),
+			  FOREIGN KEY (twitter_user_id) REFERENCES twitter_users (user_id)
+			) ENGINE InnoDB CHARSET utf8mb4`,
+			`CREATE TABLE following_snapshots (
+			  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+			  twitter_user_id BIGINT UNSIGNED NOT NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL,
+			  PRIMARY KEY (id),
+			  FOREIGN KEY (twitter_user_id) REFERENCES twitter_users (user_id)
+			) ENGINE InnoDB CHARSET utf8mb4`,
+			`CREATE TABLE followings (
+			  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+			  following_snapshot_id BIGINT UNSIGNED NOT NULL,
+			  following_twitter_user_id BIGINT UNSIGNED NOT NULL,
+			  unfollowed TINYINT(1) NOT NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL,
+			  PRIMARY KEY (id),
+			  UNIQUE KEY (following_snapshot_id, following_twitter_user_id),
+			  FOREIGN KEY (following_snapshot_id) REFERENCES following_snapshots (id),
+			  FOREIGN KEY (following_twitter_user_id) REFERENCES twitter_users (user_id)
+			) ENGINE InnoDB CHARSET utf8mb4`,
+			`CREATE TABLE remove_followers (
+			  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+			  twitter_user_id BIGINT UNSIGNED NOT NULL,
+			  follower_twitter_user_id BIGINT UNSIGNED NOT NULL,
+			  follower_screen_name VARCHAR(15) NOT NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL,
+			  PRIMARY KEY (id),
+			  FOREIGN KEY (twitter_user_id) REFERENCES twitter_users (user_id)
+			) ENGINE InnoDB CHARSET utf8mb4`,
+			`CREATE TABLE erase_lists (
+			  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+			  twitter_list_id BIGINT UNSIGNED NOT NULL,
+			  name VARCHAR(25) NOT NULL,
+			  mode VARCHAR(10) NOT NULL,
+			  description VARCHAR(100) NOT NULL,
+			  member_count BIGINT UNSIGNED NOT NULL,
+			  twitter_user_id BIGINT UNSIGNED NOT NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL,
+			  PRIMARY KEY (id),
+			  FOREIGN KEY (twitter_user_id) REFERENCES twitter_users (user_id)
+			) ENGINE InnoDB CHARSET utf8mb4`,
+			`CREATE TABLE erase_saved_searches (
+			  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+			  twitter_saved_search_id BIGINT UNSIGNED NOT NULL,
+			  query VARCHAR(500) NOT NULL,
+			  twitter_user_id BIGINT UNSIGNED NOT NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL,
+			  PRIMARY KEY (id),
+			  FOREIGN KEY (twitter_user_id) REFERENCES twitter_users (user_id)
+			) ENGINE InnoDB CHARSET utf8mb4`,
+		},
+		down: []string{
+			`DROP TABLE erase_saved_searches`,
+			`DROP TABLE erase_lists`,
+			`DROP TABLE remove_followers`,
+			`DROP TABLE followings`,
+			`DROP TABLE following_snapshots`,
+			`DROP TABLE wipe_jobs`,
+			`DROP TABLE erase_direct_messages`,
+			`DROP TABLE erase_like_errors`,
+			`DROP TABLE erase_likes`,
+			`DROP TABLE archived_tweets`,
+		},
+	},
+	{
+		version: 5,
+		name:    "unique_erase_tweets_and_collapse_erase_errors",
+		// The last error row of each tweet is kept with the count of the collapsed rows.
+		up: []string{
+			`DELETE e FROM erase_tweets e JOIN erase_tweets e2 ON e.twitter_user_id = e2.twitter_user_id
+			  AND e.twitter_tweet_id = e2.twitter_tweet_id AND e.id > e2.id`,
+			`ALTER TABLE erase_tweets ADD UNIQUE KEY twitter_user_id_twitter_tweet_id (twitter_user_id, twitter_tweet_id)`,
+			`ALTER TABLE erase_errors ADD attempts INT UNSIGNED NOT NULL DEFAULT 1 AFTER twitter_tweet_id,
+			  ADD first_error_at DATETIME NULL AFTER error_message, ADD last_error_at DATETIME NULL AFTER first_error_at`,
+			`UPDATE erase_errors e JOIN (
+			    SELECT MAX(id) AS id, COUNT(*) AS attempts, MIN(created_at) AS first_error_at
+			    FROM erase_errors GROUP BY tried_twitter_user_id, twitter_tweet_id
+			  ) g ON e.id = g.id
+			  SET e.attempts = g.attempts, e.first_error_at = g.first_error_at,
+			  e.last_error_at = e.created_at, e.created_at = g.first_error_at`,
+			`DELETE FROM erase_errors WHERE first_error_at IS NULL`,
+			`ALTER TABLE erase_errors ALTER attempts DROP DEFAULT,
+			  MODIFY first_error_at DATETIME NOT NULL, MODIFY last_error_at DATETIME NOT NULL,
+			  ADD UNIQUE KEY tried_twitter_user_id_twitter_tweet_id (tried_twitter_user_id, twitter_tweet_id)`,
+		},
+		// The collapsed error rows are not restored.
+		down: []string{
+			`ALTER TABLE erase_errors DROP INDEX tried_twitter_user_id_twitter_tweet_id,
+			  DROP attempts, DROP first_error_at, DROP last_error_at`,
+			`ALTER TABLE erase_tweets ADD INDEX (twitter_user_id), DROP INDEX twitter_user_id_twitter_tweet_id`,
+		},
+	},
+	{
+		version: 6,
+		name:    "create_erase_runs",
+		up: []string{
+			`CREATE TABLE erase_runs (
+			  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+			  twitter_user_id BIGINT UNSIGNED NOT NULL,
+			  command VARCHAR(64) NOT NULL,
+			  sources VARCHAR(255) NOT NULL,
+			  filters TEXT NOT NULL,
+			  options TEXT NOT NULL,
+			  host VARCHAR(255) NOT NULL,
+			  tool_version VARCHAR(64) NOT NULL,
+			  target_count INT UNSIGNED NOT NULL,
+			  erased_count INT UNSIGNED NOT NULL,
+			  error_count INT UNSIGNED NOT NULL,
+			  started_at DATETIME NOT NULL,
+			  ended_at DATETIME NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL,
+			  PRIMARY KEY (id),
+			  FOREIGN KEY (twitter_user_id) REFERENCES twitter_users (user_id)
+			) ENGINE InnoDB CHARSET utf8mb4`,
+			`ALTER TABLE erase_tweets ADD run_id BIGINT UNSIGNED NULL AFTER id,
+			  ADD CONSTRAINT erase_tweets_run_id FOREIGN KEY (run_id) REFERENCES erase_runs (id)`,
+			`ALTER TABLE erase_errors ADD run_id BIGINT UNSIGNED NULL AFTER id,
+			  ADD CONSTRAINT erase_errors_run_id FOREIGN KEY (run_id) REFERENCES erase_runs (id)`,
+		},
+		down: []string{
+			`ALTER TABLE erase_errors DROP FOREIGN KEY erase_errors_run_id`,
+			`ALTER TABLE erase_errors DROP run_id`,
+			`ALTER TABLE erase_tweets DROP FOREIGN KEY erase_tweets_run_id`,
+			`ALTER TABLE erase_tweets DROP run_id`,
+			`DROP TABLE erase_runs`,
+		},
+	},
+	{
+		version: 7,
+		name:    "add_erase_tweets_tweet_hash",
+		up: []string{
+			`ALTER TABLE erase_tweets ADD tweet_hash VARCHAR(64) NOT NULL DEFAULT '' AFTER tweet`,
+		},
+		down: []string{
+			`ALTER TABLE erase_tweets DROP tweet_hash`,
+		},
+	},
+	{
+		version: 8,
+		name:    "add_remove_followers_status",
+		// The rows before the status are inserted after the unblock, so they are removed.
+		up: []string{
+			`ALTER TABLE remove_followers ADD status VARCHAR(16) NOT NULL DEFAULT 'removed' AFTER follower_screen_name,
+			  ADD INDEX twitter_user_id_status (twitter_user_id, status)`,
+			`ALTER TABLE remove_followers ALTER status DROP DEFAULT`,
+		},
+		down: []string{
+			`ALTER TABLE remove_followers DROP INDEX twitter_user_id_status, DROP status`,
+		},
+	},
+	{
+		version: 9,
+		name:    "fix_erase_errors_last_error_at",
+		// MySQL does not define the order of the assignments of the multiple-table UPDATE,
+		// so version 5 may set last_error_at to the first error time assigned to created_at in the same statement.
+		// updated_at of the kept row is the time of the last error until now, so last_error_at is taken from it.
+		up: []string{
+			`UPDATE erase_errors SET last_error_at = updated_at WHERE last_error_at < updated_at`,
+		},
+		// The former last_error_at is not restored.
+		down: []string{},
+	},
+}
+
+// SchemaMigrationService is schema migrations table service.
+type SchemaMigrationService struct {
+	db *sql.DB
+	pr prepareRunner
+}
+
+// NewSchemaMigrationService is create schema migration service.
+func NewSchemaMigrationService(db *sql.DB) SchemaMigrationService {
+	return SchemaMigrationService{db: db, pr: newPrepareRunner(db)}
+}
+
+// Version returns the latest applied version. It is 0 if no migration is applied.
+func (s SchemaMigrationService) Version() (uint64, error) {
+	query, args, err := sq.Select("COALESCE(MAX(version), 0)").From(model.SchemaMigrationTableName).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := s.pr.Query(query, args...)
+	if isNoSuchTable(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var version uint64
+	for rows.Next() {
+		if err := rows.Scan(&version); err != nil {
+			return 0, err
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// LatestVersion returns the version which this program expects.
+func (s SchemaMigrationService) LatestVersion() uint64 {
+	return migrations[len(migrations)-1].version
+}
+
+// Statuses returns all migrations with the applied time.
+func (s SchemaMigrationService) Statuses() ([]*model.SchemaMigration, error) {
+	appliedAts, err := s.appliedAts()
+	if err != nil {
+		return nil, err
+	}
+
+	sms := make([]*model.SchemaMigration, len(migrations))
+	for i, m := range migrations {
+		sms[i] = &model.SchemaMigration{Version: m.version, Name: m.name, AppliedAt: appliedAts[m.version]}
+	}
+
+	return sms, nil
+}
+
+func (s SchemaMigrationService) appliedAts() (map[uint64]time.Time, error) {
+	query, args, err := sq.Select("version", "applied_at").From(model.SchemaMigrationTableName).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	appliedAts := map[uint64]time.Time{}
+	rows, err := s.pr.Query(query, args...)
+	if isNoSuchTable(err) {
+		return appliedAts, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version uint64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		appliedAts[version] = appliedAt
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return appliedAts, nil
+}
+
+// Up applies all pending migrations in order and returns the applied migrations.
+// MySQL commits DDL implicitly, so a failed migration is not rolled back
+// and must be fixed by hand before retrying.
+func (s SchemaMigrationService) Up() ([]*model.SchemaMigration, error) {
+	_, err := s.db.Exec("CREATE TABLE IF NOT EXISTS " + model.SchemaMigrationTableName + ` (
+  version BIGINT UNSIGNED NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  applied_at DATETIME NOT NULL,
+  PRIMARY KEY (version)
+) ENGINE InnoDB CHARSET utf8mb4`)
+	if err != nil {
+		return nil, err
+	}
+
+	appliedAts, err := s.appliedAts()
+	if err != nil {
+		return nil, err
+	}
+
+	var sms []*model.SchemaMigration
+	for _, m := range migrations {
+		if _, ok := appliedAts[m.version]; ok {
+			continue
+		}
+
+		for _, stmt := range m.up {
+			if _, err := s.db.Exec(stmt); err != nil {
+				return sms, err
+			}
+		}
+
+		sm := &model.SchemaMigration{Version: m.version, Name: m.name, AppliedAt: time.Now().UTC()}
+		query, args, err := sq.Insert(model.SchemaMigrationTableName).Columns("version", "name", "applied_at").
+			Values(sm.Version, sm.Name, sm.AppliedAt).ToSql()
+		if err != nil {
+			return sms, err
+		}
+
+		if _, err := s.pr.Exec(query, args...); err != nil {
+			return sms, err
+		}
+
+		sms = append(sms, sm)
+	}
+
+	return sms, nil
+}
+
+// Down rolls back the latest applied migration and returns it.
+// It returns nil if no migration is applied.
+func (s SchemaMigrationService) Down() (*model.SchemaMigration, error) {
+	version, err := s.Version()
+	if err != nil {
+		return nil, err
+	} else if version == 0 {
+		return nil, nil
+	}
+
+	for _, m := range migrations {
+		if m.version != version {
+			continue
+		}
+
+		for _, stmt := range m.down {
+			if _, err := s.db.Exec(stmt); err != nil {
+				return nil, err
+			}
+		}
+
+		query, args, err := sq.Delete(model.SchemaMigrationTableName).Where(sq.Eq{"version": version}).ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := s.pr.Exec(query, args...); err != nil {
+			return nil, err
+		}
+
+		return &model.SchemaMigration{Version: m.version, Name: m.name}, nil
+	}
+
+	return nil, errors.Errorf("Migration version %d is unknown.", version)
+}
+
+func isNoSuchTable(err error) bool {
+	mysqlErr, ok := err.(*mysql.MySQLError)
+	return ok && mysqlErr.Number == errNoSuchTable
+}
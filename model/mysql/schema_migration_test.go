@@ -0,0 +1,84 @@
+package mysql_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/mysql"
+	"github.com/stretchr/testify/suite"
+)
+
+type schemaMigrationSuite struct {
+	suite.Suite
+
+	db      *sql.DB
+	service model.SchemaMigrationService
+}
+
+func TestSchemaMigrationSuite(t *testing.T) {
+	suite.Run(t, new(schemaMigrationSuite))
+}
+
+func (s *schemaMigrationSuite) SetupSuite() {
+	db, err := openTestDB()
+	s.NoError(err)
+
+	s.db = db
+	s.service = mysql.NewSchemaMigrationService(db)
+}
+
+func (s *schemaMigrationSuite) SetupTest() {
+	// Migrate test db to the latest.
+	_, err := s.service.Up()
+	s.NoError(err)
+}
+
+func (s *schemaMigrationSuite) TestStatuses() {
+	sms, err := s.service.Statuses()
+	s.NoError(err)
+	s.Len(sms, int(s.service.LatestVersion()))
+
+	for i, sm := range sms {
+		s.Equal(uint64(i+1), sm.Version)
+		s.NotEmpty(sm.Name)
+		s.True(sm.Applied())
+	}
+}
+
+func (s *schemaMigrationSuite) TestDownUp() {
+	latest := s.service.LatestVersion()
+	version, err := s.service.Version()
+	s.NoError(err)
+	s.Equal(latest, version)
+
+	// Nothing to apply.
+	sms, err := s.service.Up()
+	s.NoError(err)
+	s.Len(sms, 0)
+
+	sm, err := s.service.Down()
+	s.NoError(err)
+	s.Equal(latest, sm.Version)
+
+	version, err = s.service.Version()
+	s.NoError(err)
+	s.Equal(latest-1, version)
+
+	statuses, err := s.service.Statuses()
+	s.NoError(err)
+	s.False(statuses[latest-1].Applied())
+
+	sms, err = s.service.Up()
+	s.NoError(err)
+	s.Len(sms, 1)
+	s.Equal(latest, sms[0].Version)
+
+	version, err = s.service.Version()
+	s.NoError(err)
+	s.Equal(latest, version)
+}
+
+func (s *schemaMigrationSuite) TearDownSuite() {
+	s.db.Close()
+}
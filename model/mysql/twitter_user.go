@@ -137,3 +137,36 @@ func (s TwitterUserService) selectForUpdate(userID uint64) (*model.TwitterUser,
 
 	return tu, nil
 }
+
+// TwitterUsers returns the stored twitter users in order of the user id.
+func (s TwitterUserService) TwitterUsers() ([]*model.TwitterUser, error) {
+	query, args, err := sq.Select("user_id", "screen_name", "name", "lang", "updated_at", "created_at").
+		From(model.TwitterUserTableName).OrderBy("user_id").ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pr.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tus []*model.TwitterUser
+	for rows.Next() {
+		var tu model.TwitterUser
+		err := rows.Scan(&tu.UserID, &tu.ScreenName, &tu.Name, &tu.Lang, &tu.UpdatedAt, &tu.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		tus = append(tus, &tu)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return tus, nil
+}
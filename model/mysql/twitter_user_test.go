@@ -25,7 +25,7 @@ func TestTwitterUserSuite(t *testing.T) {
 }
 
 func (s *twitterUserSuite) SetupSuite() {
-	db, err := mysql.Open("root", "", "tweeraser_test")
+	db, err := openTestDB()
 	s.NoError(err)
 
 	s.db = db
@@ -44,7 +44,7 @@ func (s *twitterUserSuite) SetupTest() {
 
 func (s *twitterUserSuite) TestInsertUpdate() {
 	tu := &model.TwitterUser{UserID: math.MaxUint64,
-		ScreenName: "screen_name", Name: "name", Lang: "en"}
+		ScreenName: "screen_name", Name: "name🍣", Lang: "en"}
 	err := s.service.InsertUpdate(tu)
 	s.NoError(err)
 
@@ -98,6 +98,25 @@ func (s *twitterUserSuite) TestInsertUpdate() {
 	s.NoError(rows.Close())
 }
 
+func (s *twitterUserSuite) TestTwitterUsers() {
+	tus, err := s.service.TwitterUsers()
+	s.NoError(err)
+	s.Empty(tus)
+
+	s.NoError(s.service.InsertUpdate(&model.TwitterUser{UserID: 2, ScreenName: "second", Name: "name2", Lang: "ja"}))
+	s.NoError(s.service.InsertUpdate(&model.TwitterUser{UserID: 1, ScreenName: "first", Name: "name1", Lang: "en"}))
+
+	tus, err = s.service.TwitterUsers()
+	s.NoError(err)
+	s.Len(tus, 2)
+	s.Equal(uint64(1), tus[0].UserID)
+	s.Equal("first", tus[0].ScreenName)
+	s.Equal("name1", tus[0].Name)
+	s.Equal("en", tus[0].Lang)
+	s.Equal(uint64(2), tus[1].UserID)
+	s.Equal("second", tus[1].ScreenName)
+}
+
 func (s *twitterUserSuite) TearDownSuite() {
 	s.db.Close()
 }
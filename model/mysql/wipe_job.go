@@ -0,0 +1,92 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+// WipeJobService is wipe jobs table service.
+type WipeJobService struct {
+	pr prepareRunner
+}
+
+// NewWipeJobService is create wipe job service.
+func NewWipeJobService(db *sql.DB) WipeJobService {
+	return WipeJobService{pr: newPrepareRunner(db)}
+}
+
+// LatestUnfinished returns the latest unfinished job of the user.
+// If there is no unfinished job, returns nil.
+func (s WipeJobService) LatestUnfinished(userID uint64) (*model.WipeJob, error) {
+	query, args, err := sq.Select("*").From(model.WipeJobTableName).
+		Where(sq.Eq{"twitter_user_id": userID, "finished": false}).
+		OrderBy("id DESC").Limit(1).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	wj := &model.WipeJob{}
+	err = s.pr.QueryRow(query, args...).Scan(&wj.ID, &wj.TwitterUserID,
+		&wj.CompletedStages, &wj.Finished, &wj.UpdatedAt, &wj.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return wj, nil
+}
+
+// Insert is insert to wipe job table.
+func (s WipeJobService) Insert(wj *model.WipeJob) (uint64, error) {
+	now := time.Now().UTC()
+	query, args, err := sq.Insert(model.WipeJobTableName).Columns(
+		"twitter_user_id", "completed_stages", "finished", "updated_at", "created_at").
+		Values(wj.TwitterUserID, wj.CompletedStages, wj.Finished, now, now).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := s.pr.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	lastInsertID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastInsertID), nil
+}
+
+// Update is update to wipe job table.
+func (s WipeJobService) Update(wj *model.WipeJob) error {
+	setMap := map[string]interface{}{"completed_stages": wj.CompletedStages,
+		"finished": wj.Finished, "updated_at": time.Now().UTC()}
+	query, args, err := sq.Update(model.WipeJobTableName).
+		SetMap(setMap).Where(sq.Eq{"id": wj.ID}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	res, err := s.pr.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	updateCnt, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if updateCnt < 1 {
+		return errors.Errorf("row not found: %d", wj.ID)
+	}
+
+	return nil
+}
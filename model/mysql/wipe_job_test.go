@@ -0,0 +1,105 @@
+package mysql_test
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/mysql"
+	"github.com/stretchr/testify/suite"
+)
+
+type wipeJobSuite struct {
+	suite.Suite
+
+	db      *sql.DB
+	service model.WipeJobService
+}
+
+func TestWipeJobSuite(t *testing.T) {
+	suite.Run(t, new(wipeJobSuite))
+}
+
+func (s *wipeJobSuite) SetupSuite() {
+	db, err := openTestDB()
+	s.NoError(err)
+
+	s.db = db
+	s.service = mysql.NewWipeJobService(db)
+}
+
+func (s *wipeJobSuite) SetupTest() {
+	// Reset test db.
+	_, err := s.db.Exec("SET FOREIGN_KEY_CHECKS = 0")
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.WipeJobTableName))
+	s.NoError(err)
+	_, err = s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", model.TwitterUserTableName))
+	s.NoError(err)
+	_, err = s.db.Exec("SET FOREIGN_KEY_CHECKS = 1")
+	s.NoError(err)
+
+	// Create test twitter users.
+	tus := mysql.NewTwitterUserService(s.db)
+	for _, uid := range []uint64{1, 2, math.MaxUint64} {
+		tu := &model.TwitterUser{UserID: uid}
+		err = tus.InsertUpdate(tu)
+		s.NoError(err)
+	}
+}
+
+func (s *wipeJobSuite) TestInsertUpdate() {
+	userID := uint64(math.MaxUint64)
+
+	// Not exist.
+	wj, err := s.service.LatestUnfinished(userID)
+	s.NoError(err)
+	s.Nil(wj)
+
+	// Insert.
+	for i := 1; i <= 2; i++ {
+		insertID, err := s.service.Insert(&model.WipeJob{TwitterUserID: userID})
+		s.NoError(err)
+		s.Equal(uint64(i), insertID)
+	}
+
+	wj, err = s.service.LatestUnfinished(userID)
+	s.NoError(err)
+	s.Equal(uint64(2), wj.ID)
+	s.Equal(userID, wj.TwitterUserID)
+	s.Equal("", wj.CompletedStages)
+	s.False(wj.Finished)
+
+	// Update.
+	wj.Complete("tweets")
+	err = s.service.Update(wj)
+	s.NoError(err)
+
+	actual, err := s.service.LatestUnfinished(userID)
+	s.NoError(err)
+	s.Equal("tweets", actual.CompletedStages)
+
+	// Finish.
+	wj.Finished = true
+	err = s.service.Update(wj)
+	s.NoError(err)
+
+	actual, err = s.service.LatestUnfinished(userID)
+	s.NoError(err)
+	s.Equal(uint64(1), actual.ID)
+
+	// Other user.
+	actual, err = s.service.LatestUnfinished(1)
+	s.NoError(err)
+	s.Nil(actual)
+
+	// Not exist row.
+	err = s.service.Update(&model.WipeJob{ID: 100})
+	s.Error(err)
+}
+
+func (s *wipeJobSuite) TearDownSuite() {
+	s.db.Close()
+}
@@ -0,0 +1,197 @@
+package postgres
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	sq "github.com/Masterminds/squirrel"
+)
+
+var eraseErrorColumns = []string{"run_id", "tried_twitter_user_id", "twitter_tweet_id", "attempts",
+	"status_code", "error_message", "first_error_at", "last_error_at", "updated_at", "created_at"}
+
+// eraseErrorUpsertSuffix counts up the attempts of the existing error and keeps the last error.
+const eraseErrorUpsertSuffix = "ON CONFLICT (tried_twitter_user_id, twitter_tweet_id) DO UPDATE SET " +
+	"run_id = EXCLUDED.run_id, attempts = erase_errors.attempts + EXCLUDED.attempts, status_code = EXCLUDED.status_code, " +
+	"error_message = EXCLUDED.error_message, last_error_at = EXCLUDED.last_error_at, updated_at = EXCLUDED.updated_at"
+
+// EraseErrorService is erase errors table service.
+type EraseErrorService struct {
+	pr prepareRunner
+}
+
+// NewEraseErrorService is create erase error service.
+func NewEraseErrorService(db *sql.DB) EraseErrorService {
+	return EraseErrorService{pr: newPrepareRunner(db)}
+}
+
+// TweetNotFoundIDs return not found tweet ids from argument ids.
+func (s EraseErrorService) TweetNotFoundIDs(userID uint64, ids []uint64) ([]uint64, error) {
+	query, args, err := psql.Select("twitter_tweet_id").From(model.EraseErrorTableName).
+		Where(sq.Eq{"tried_twitter_user_id": userID,
+			"status_code": http.StatusNotFound, "twitter_tweet_id": ids}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pr.Query(query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tweetIDs []uint64
+	for rows.Next() {
+		var id uint64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		tweetIDs = append(tweetIDs, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return tweetIDs, nil
+}
+
+// Insert is insert to erase error table.
+// If the error of the same tweet exists, it counts up the attempts and returns the id of the row.
+func (s EraseErrorService) Insert(ee *model.EraseError) (uint64, error) {
+	now := time.Now().UTC()
+	firstErrorAt, lastErrorAt := ee.ErrorTimes(now)
+	query, args, err := psql.Insert(model.EraseErrorTableName).Columns(eraseErrorColumns...).
+		Values(nullableID(ee.RunID), ee.TriedTwitterUserID, ee.TwitterTweetID, 1, ee.StatusCode, ee.ErrorMessage, firstErrorAt, lastErrorAt, now, now).
+		Suffix(eraseErrorUpsertSuffix + " RETURNING id").ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	// Postgres has no last insert id, so return the id of RETURNING.
+	var insertID uint64
+	err = s.pr.QueryRow(query, args...).Scan(&insertID)
+	if err != nil {
+		return 0, err
+	}
+
+	return insertID, nil
+}
+
+// BulkInsert inserts erase errors with multi-row statements.
+func (s EraseErrorService) BulkInsert(ees []*model.EraseError) error {
+	ees = model.CollapseEraseErrors(ees)
+	for len(ees) > 0 {
+		cnt := bulkInsertRowCnt
+		if len(ees) < cnt {
+			cnt = len(ees)
+		}
+
+		if err := s.bulkInsert(ees[:cnt]); err != nil {
+			return err
+		}
+
+		ees = ees[cnt:]
+	}
+
+	return nil
+}
+
+func (s EraseErrorService) bulkInsert(ees []*model.EraseError) error {
+	now := time.Now().UTC()
+	b := psql.Insert(model.EraseErrorTableName).Columns(eraseErrorColumns...)
+	for _, ee := range ees {
+		firstErrorAt, lastErrorAt := ee.ErrorTimes(now)
+		b = b.Values(nullableID(ee.RunID), ee.TriedTwitterUserID, ee.TwitterTweetID, ee.Attempts,
+			ee.StatusCode, ee.ErrorMessage, firstErrorAt, lastErrorAt, now, now)
+	}
+
+	query, args, err := b.Suffix(eraseErrorUpsertSuffix).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.pr.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// TweetStatusCodes returns the status code of the error by the tweet id of the tried user.
+func (s EraseErrorService) TweetStatusCodes(userID uint64) (map[uint64]uint16, error) {
+	query, args, err := psql.Select("twitter_tweet_id", "status_code").From(model.EraseErrorTableName).
+		Where(sq.Eq{"tried_twitter_user_id": userID}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pr.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statusCodes := map[uint64]uint16{}
+	for rows.Next() {
+		var tweetID uint64
+		var statusCode uint16
+		err := rows.Scan(&tweetID, &statusCode)
+		if err != nil {
+			return nil, err
+		}
+
+		statusCodes[tweetID] = statusCode
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return statusCodes, nil
+}
+
+// RetryTweetIDs returns the tweet ids of the errors except not found, that can be erased by retry.
+func (s EraseErrorService) RetryTweetIDs(userID uint64) ([]uint64, error) {
+	query, args, err := psql.Select("twitter_tweet_id").From(model.EraseErrorTableName).
+		Where(sq.And{sq.Eq{"tried_twitter_user_id": userID},
+			sq.NotEq{"status_code": http.StatusNotFound}}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pr.Query(query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tweetIDs []uint64
+	for rows.Next() {
+		var id uint64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		tweetIDs = append(tweetIDs, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return tweetIDs, nil
+}
@@ -0,0 +1,249 @@
+package postgres_test
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/postgres"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/suite"
+)
+
+type eraseErrorSuite struct {
+	suite.Suite
+
+	db      *sql.DB
+	service model.EraseErrorService
+}
+
+func TestEraseErrorSuite(t *testing.T) {
+	suite.Run(t, new(eraseErrorSuite))
+}
+
+func (s *eraseErrorSuite) SetupSuite() {
+	db, err := openTestDB()
+	s.NoError(err)
+
+	s.db = db
+	s.service = postgres.NewEraseErrorService(db)
+}
+
+func (s *eraseErrorSuite) SetupTest() {
+	// Reset test db.
+	_, err := s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s, %s RESTART IDENTITY CASCADE",
+		model.EraseErrorTableName, model.TwitterUserTableName))
+	s.NoError(err)
+
+	// Create test twitter users.
+	tus := postgres.NewTwitterUserService(s.db)
+	for _, uid := range []uint64{1, 2, math.MaxInt64} {
+		tu := &model.TwitterUser{UserID: uid}
+		err = tus.InsertUpdate(tu)
+		s.NoError(err)
+	}
+}
+
+func (s *eraseErrorSuite) TestTweetNotFoundIDs() {
+	userID := uint64(1)
+	cnt := 1000
+	ids := make([]uint64, cnt)
+	dummyIDs := make([]uint64, cnt)
+	for i := 1; i <= cnt; i++ {
+		dummyID := math.MaxInt64 - uint64(i)
+		ids[i-1] = dummyID
+		dummyIDs[i-1] = dummyID
+		ee := &model.EraseError{TriedTwitterUserID: userID, TwitterTweetID: dummyID, StatusCode: http.StatusNotFound}
+		insertID, err := s.service.Insert(ee)
+		s.NoError(err)
+		s.Equal(uint64(i), insertID)
+	}
+
+	// Other status.
+	ee := &model.EraseError{TriedTwitterUserID: userID, TwitterTweetID: 100,
+		StatusCode: http.StatusInternalServerError, ErrorMessage: "Error: status 500."}
+	insertID, err := s.service.Insert(ee)
+	s.NoError(err)
+	s.Equal(uint64(cnt+1), insertID)
+
+	// Other user.
+	ee = &model.EraseError{TriedTwitterUserID: 2, TwitterTweetID: 100,
+		StatusCode: http.StatusNotFound, ErrorMessage: "Error: status 404."}
+	insertID, err = s.service.Insert(ee)
+	s.NoError(err)
+	s.Equal(uint64(cnt+2), insertID)
+
+	ids = append(ids, []uint64{ee.TwitterTweetID, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}...)
+	tweetIDs, err := s.service.TweetNotFoundIDs(userID, ids)
+	s.NoError(err)
+	s.Len(tweetIDs, cnt)
+
+	for _, dummyID := range dummyIDs {
+		var isExist bool
+		for _, tweetID := range tweetIDs {
+			if tweetID == dummyID {
+				isExist = true
+				break
+			}
+		}
+
+		s.True(isExist)
+	}
+}
+
+func (s *eraseErrorSuite) TestInsert() {
+	ee := &model.EraseError{TriedTwitterUserID: math.MaxInt64, TwitterTweetID: math.MaxInt64,
+		StatusCode: http.StatusNotFound, ErrorMessage: "Error: status 404."}
+	insertID, err := s.service.Insert(ee)
+	s.NoError(err)
+	s.Equal(uint64(1), insertID)
+
+	rows, err := sq.Select("id", "COALESCE(run_id, 0)", "tried_twitter_user_id", "twitter_tweet_id", "attempts",
+		"status_code", "error_message", "first_error_at", "last_error_at", "updated_at", "created_at").
+		From(model.EraseErrorTableName).RunWith(s.db).Query()
+	s.NoError(err)
+
+	var cnt int
+	for rows.Next() {
+		var actual model.EraseError
+		err := rows.Scan(&actual.ID, &actual.RunID, &actual.TriedTwitterUserID, &actual.TwitterTweetID,
+			&actual.Attempts, &actual.StatusCode, &actual.ErrorMessage, &actual.FirstErrorAt, &actual.LastErrorAt,
+			&actual.UpdatedAt, &actual.CreatedAt)
+		s.NoError(err)
+
+		s.Equal(insertID, actual.ID)
+		s.Equal(uint64(0), actual.RunID)
+		s.Equal(ee.TriedTwitterUserID, actual.TriedTwitterUserID)
+		s.Equal(ee.TwitterTweetID, actual.TwitterTweetID)
+		s.Equal(uint32(1), actual.Attempts)
+		s.Equal(ee.StatusCode, actual.StatusCode)
+		s.Equal(ee.ErrorMessage, actual.ErrorMessage)
+
+		threeSecAgo := time.Now().UTC().Add(-3 * time.Second)
+		s.True(actual.FirstErrorAt.After(threeSecAgo))
+		s.True(actual.LastErrorAt.After(threeSecAgo))
+		s.True(actual.UpdatedAt.After(threeSecAgo))
+		s.True(actual.CreatedAt.After(threeSecAgo))
+
+		cnt++
+	}
+
+	s.Equal(1, cnt)
+	s.NoError(rows.Err())
+	s.NoError(rows.Close())
+
+	// Duplicate error.
+	ee = &model.EraseError{TriedTwitterUserID: math.MaxInt64, TwitterTweetID: math.MaxInt64,
+		StatusCode: http.StatusInternalServerError, ErrorMessage: "Error: status 500."}
+	dupInsertID, err := s.service.Insert(ee)
+	s.NoError(err)
+	s.Equal(insertID, dupInsertID)
+
+	var actual model.EraseError
+	err = sq.Select("COUNT(*)", "MAX(attempts)", "MAX(status_code)", "MAX(error_message)").
+		From(model.EraseErrorTableName).RunWith(s.db).QueryRow().
+		Scan(&cnt, &actual.Attempts, &actual.StatusCode, &actual.ErrorMessage)
+	s.NoError(err)
+	s.Equal(1, cnt)
+	s.Equal(uint32(2), actual.Attempts)
+	s.Equal(ee.StatusCode, actual.StatusCode)
+	s.Equal(ee.ErrorMessage, actual.ErrorMessage)
+}
+
+func (s *eraseErrorSuite) TestBulkInsert() {
+	userID := uint64(1)
+	cnt := 2500
+	ees := make([]*model.EraseError, cnt)
+	for i := 0; i < cnt; i++ {
+		ees[i] = &model.EraseError{TriedTwitterUserID: userID, TwitterTweetID: uint64(i + 1),
+			StatusCode: http.StatusNotFound, ErrorMessage: "Error: status 404."}
+	}
+
+	err := s.service.BulkInsert(ees)
+	s.NoError(err)
+
+	tweetIDs, err := s.service.TweetNotFoundIDs(userID, []uint64{1, uint64(cnt), uint64(cnt + 1)})
+	s.NoError(err)
+	s.Len(tweetIDs, 2)
+
+	// Duplicate errors are collapsed and counted up.
+	err = s.service.BulkInsert([]*model.EraseError{ees[0], ees[0],
+		{TriedTwitterUserID: userID, TwitterTweetID: 1, StatusCode: http.StatusInternalServerError}})
+	s.NoError(err)
+
+	tweetIDs, err = s.service.TweetNotFoundIDs(userID, []uint64{1, uint64(cnt)})
+	s.NoError(err)
+	s.Equal([]uint64{uint64(cnt)}, tweetIDs)
+
+	var attempts uint32
+	err = sq.Select("attempts").From(model.EraseErrorTableName).
+		Where(sq.Eq{"tried_twitter_user_id": userID, "twitter_tweet_id": 1}).PlaceholderFormat(sq.Dollar).
+		RunWith(s.db).QueryRow().Scan(&attempts)
+	s.NoError(err)
+	s.Equal(uint32(4), attempts)
+}
+
+func (s *eraseErrorSuite) TestBulkInsertErrorTimes() {
+	firstErrorAt := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	lastErrorAt := firstErrorAt.Add(time.Hour)
+	err := s.service.BulkInsert([]*model.EraseError{{TriedTwitterUserID: 1, TwitterTweetID: 1,
+		StatusCode: http.StatusInternalServerError, FirstErrorAt: firstErrorAt, LastErrorAt: lastErrorAt}})
+	s.NoError(err)
+
+	var actual model.EraseError
+	err = sq.Select("first_error_at", "last_error_at").From(model.EraseErrorTableName).
+		Where(sq.Eq{"tried_twitter_user_id": 1, "twitter_tweet_id": 1}).PlaceholderFormat(sq.Dollar).
+		RunWith(s.db).QueryRow().Scan(&actual.FirstErrorAt, &actual.LastErrorAt)
+	s.NoError(err)
+	s.True(firstErrorAt.Equal(actual.FirstErrorAt))
+	s.True(lastErrorAt.Equal(actual.LastErrorAt))
+}
+
+func (s *eraseErrorSuite) TestTweetStatusCodes() {
+	userID := uint64(1)
+	ees := []*model.EraseError{
+		{TriedTwitterUserID: userID, TwitterTweetID: 1, StatusCode: http.StatusNotFound},
+		{TriedTwitterUserID: userID, TwitterTweetID: 2, StatusCode: http.StatusNotFound},
+		{TriedTwitterUserID: userID, TwitterTweetID: 3},
+		{TriedTwitterUserID: userID, TwitterTweetID: 3, StatusCode: http.StatusInternalServerError},
+		{TriedTwitterUserID: 2, TwitterTweetID: 4, StatusCode: http.StatusForbidden},
+	}
+	err := s.service.BulkInsert(ees)
+	s.NoError(err)
+
+	// The status code of the last error of the tweet.
+	statusCodes, err := s.service.TweetStatusCodes(userID)
+	s.NoError(err)
+	s.Equal(map[uint64]uint16{1: http.StatusNotFound, 2: http.StatusNotFound, 3: http.StatusInternalServerError}, statusCodes)
+
+	// Not exist.
+	statusCodes, err = s.service.TweetStatusCodes(math.MaxInt64)
+	s.NoError(err)
+	s.Len(statusCodes, 0)
+}
+
+func (s *eraseErrorSuite) TestRetryTweetIDs() {
+	userID := uint64(1)
+	ees := []*model.EraseError{
+		{TriedTwitterUserID: userID, TwitterTweetID: 1, StatusCode: http.StatusNotFound},
+		{TriedTwitterUserID: userID, TwitterTweetID: 2, StatusCode: http.StatusInternalServerError},
+		{TriedTwitterUserID: userID, TwitterTweetID: 3},
+		{TriedTwitterUserID: 2, TwitterTweetID: 4, StatusCode: http.StatusForbidden},
+	}
+	err := s.service.BulkInsert(ees)
+	s.NoError(err)
+
+	tweetIDs, err := s.service.RetryTweetIDs(userID)
+	s.NoError(err)
+	s.Len(tweetIDs, 2)
+	s.Contains(tweetIDs, uint64(2))
+	s.Contains(tweetIDs, uint64(3))
+}
+
+func (s *eraseErrorSuite) TearDownSuite() {
+	s.db.Close()
+}
@@ -0,0 +1,189 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	sq "github.com/Masterminds/squirrel"
+)
+
+var eraseTweetColumns = []string{"run_id", "twitter_tweet_id", "tweet", "tweet_hash", "raw_json",
+	"media_paths", "posted_at", "twitter_user_id", "updated_at", "created_at"}
+
+// eraseTweetUpsertSuffix updates the existing tweet of the same user.
+const eraseTweetUpsertSuffix = "ON CONFLICT (twitter_user_id, twitter_tweet_id) DO UPDATE SET run_id = EXCLUDED.run_id, " +
+	"tweet = EXCLUDED.tweet, tweet_hash = EXCLUDED.tweet_hash, raw_json = EXCLUDED.raw_json, media_paths = EXCLUDED.media_paths, " +
+	"posted_at = EXCLUDED.posted_at, updated_at = EXCLUDED.updated_at"
+
+// EraseTweetService is postgres database service.
+type EraseTweetService struct {
+	pr prepareRunner
+}
+
+// NewEraseTweetService is create service.
+func NewEraseTweetService(db *sql.DB) EraseTweetService {
+	return EraseTweetService{pr: newPrepareRunner(db)}
+}
+
+// AlreadyEraseTweetIDs return already erase ids from argument ids.
+func (s EraseTweetService) AlreadyEraseTweetIDs(userID uint64, ids []uint64) ([]uint64, error) {
+	query, args, err := psql.Select("twitter_tweet_id").From(model.EraseTweetTableName).
+		Where(sq.Eq{"twitter_user_id": userID, "twitter_tweet_id": ids}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pr.Query(query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tweetIDs []uint64
+	for rows.Next() {
+		var id uint64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		tweetIDs = append(tweetIDs, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return tweetIDs, nil
+}
+
+// Insert is insert erase_tweets table.
+// If the tweet of the same user exists, it updates the row and returns the id of it.
+func (s EraseTweetService) Insert(et *model.EraseTweet) (uint64, error) {
+	now := time.Now().UTC()
+	query, args, err := psql.Insert(model.EraseTweetTableName).Columns(eraseTweetColumns...).
+		Values(nullableID(et.RunID), et.TwitterTweetID, et.Tweet, et.TweetHash, et.RawJSON,
+			et.MediaPaths, et.PostedAt, et.TwitterUserID, now, now).
+		Suffix(eraseTweetUpsertSuffix + " RETURNING id").ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	// Postgres has no last insert id, so return the id of RETURNING.
+	var insertID uint64
+	err = s.pr.QueryRow(query, args...).Scan(&insertID)
+	if err != nil {
+		return 0, err
+	}
+
+	return insertID, nil
+}
+
+// BulkInsert inserts erase tweets with multi-row statements.
+func (s EraseTweetService) BulkInsert(ets []*model.EraseTweet) error {
+	ets = model.UniqueEraseTweets(ets)
+	for len(ets) > 0 {
+		cnt := bulkInsertRowCnt
+		if len(ets) < cnt {
+			cnt = len(ets)
+		}
+
+		if err := s.bulkInsert(ets[:cnt]); err != nil {
+			return err
+		}
+
+		ets = ets[cnt:]
+	}
+
+	return nil
+}
+
+func (s EraseTweetService) bulkInsert(ets []*model.EraseTweet) error {
+	now := time.Now().UTC()
+	b := psql.Insert(model.EraseTweetTableName).Columns(eraseTweetColumns...)
+	for _, et := range ets {
+		b = b.Values(nullableID(et.RunID), et.TwitterTweetID, et.Tweet, et.TweetHash, et.RawJSON,
+			et.MediaPaths, et.PostedAt, et.TwitterUserID, now, now)
+	}
+
+	query, args, err := b.Suffix(eraseTweetUpsertSuffix).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.pr.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CountByPostedMonth counts the erased tweets of the user by the posted month.
+func (s EraseTweetService) CountByPostedMonth(userID uint64) ([]*model.KeyCount, error) {
+	return s.countBy("to_char(posted_at, 'YYYY-MM')", userID)
+}
+
+// CountBySource counts the erased tweets of the user by the source html of the raw json.
+// The tweets without raw json are counted as the empty source.
+func (s EraseTweetService) CountBySource(userID uint64) ([]*model.KeyCount, error) {
+	return s.countBy("COALESCE(NULLIF(raw_json, '')::json->>'source', '')", userID)
+}
+
+func (s EraseTweetService) countBy(key string, userID uint64) ([]*model.KeyCount, error) {
+	query, args, err := psql.Select(key+" AS k", "COUNT(*)").From(model.EraseTweetTableName).
+		Where(sq.Eq{"twitter_user_id": userID}).GroupBy("k").OrderBy("k").ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	return queryKeyCounts(s.pr, query, args)
+}
+
+// EraseTweets returns the erased tweets of the user in order of the posted time.
+func (s EraseTweetService) EraseTweets(userID uint64) ([]*model.EraseTweet, error) {
+	query, args, err := psql.Select("id", "COALESCE(run_id, 0)", "twitter_tweet_id", "tweet", "tweet_hash", "raw_json", "media_paths",
+		"posted_at", "twitter_user_id", "updated_at", "created_at").From(model.EraseTweetTableName).
+		Where(sq.Eq{"twitter_user_id": userID}).OrderBy("posted_at", "twitter_tweet_id").ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pr.Query(query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ets []*model.EraseTweet
+	for rows.Next() {
+		var et model.EraseTweet
+		err := rows.Scan(&et.ID, &et.RunID, &et.TwitterTweetID, &et.Tweet, &et.TweetHash, &et.RawJSON, &et.MediaPaths,
+			&et.PostedAt, &et.TwitterUserID, &et.UpdatedAt, &et.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		ets = append(ets, &et)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return ets, nil
+}
+
+// ScrubContent updates the content of the erase tweets by the upsert.
+// The former row versions remain in the table file until VACUUM FULL rewrites it, plain VACUUM only marks them reusable.
+// The write-ahead log and backups keep the former content until they are recycled.
+func (s EraseTweetService) ScrubContent(ets []*model.EraseTweet) error {
+	return s.BulkInsert(ets)
+}
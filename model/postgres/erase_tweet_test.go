@@ -0,0 +1,307 @@
+package postgres_test
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/postgres"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/suite"
+)
+
+type eraseTweetTestSuite struct {
+	suite.Suite
+
+	db      *sql.DB
+	service model.EraseTweetService
+}
+
+func TestEraseTweetSuite(t *testing.T) {
+	suite.Run(t, new(eraseTweetTestSuite))
+}
+
+func (s *eraseTweetTestSuite) SetupSuite() {
+	db, err := openTestDB()
+	s.NoError(err)
+
+	s.db = db
+	s.service = postgres.NewEraseTweetService(db)
+}
+
+func (s *eraseTweetTestSuite) SetupTest() {
+	// Reset test db.
+	_, err := s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s, %s RESTART IDENTITY CASCADE",
+		model.EraseTweetTableName, model.TwitterUserTableName))
+	s.NoError(err)
+
+	// Create test twitter users.
+	tus := postgres.NewTwitterUserService(s.db)
+	for _, uid := range []uint64{1, 2, math.MaxInt64} {
+		tu := &model.TwitterUser{UserID: uid}
+		err = tus.InsertUpdate(tu)
+		s.NoError(err)
+	}
+}
+
+func (s *eraseTweetTestSuite) TestAlreadyEraseTweetIDs() {
+	userID := uint64(1)
+	cnt := 1000
+	ids := make([]uint64, cnt)
+	dummyIDs := make([]uint64, cnt)
+	for i := 1; i <= cnt; i++ {
+		dummyID := math.MaxInt64 - uint64(i)
+		ids[i-1] = dummyID
+		dummyIDs[i-1] = dummyID
+		et := &model.EraseTweet{TwitterTweetID: dummyID, TwitterUserID: userID}
+		insertID, err := s.service.Insert(et)
+		s.NoError(err)
+		s.Equal(uint64(i), insertID)
+	}
+
+	// Other user.
+	et := &model.EraseTweet{TwitterTweetID: 10000, TwitterUserID: 2}
+	insertID, err := s.service.Insert(et)
+	s.NoError(err)
+	s.Equal(uint64(cnt+1), insertID)
+
+	ids = append(ids, []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}...)
+	tweetIDs, err := s.service.AlreadyEraseTweetIDs(userID, ids)
+	s.NoError(err)
+	s.Len(tweetIDs, cnt)
+
+	for _, dummyID := range dummyIDs {
+		var isExist bool
+		for _, tweetID := range tweetIDs {
+			if tweetID == dummyID {
+				isExist = true
+				break
+			}
+		}
+
+		s.True(isExist)
+	}
+}
+
+func (s *eraseTweetTestSuite) TestInsert() {
+	// 280 characters tweet with emoji.
+	tweet280 := strings.Repeat("1234567890", 27) + "🍣🍺🍣🍺🍣🍺🍣🍺🍣🍺"
+	postedAt := time.Now().Add(-24 * time.Hour).UTC()
+	et := &model.EraseTweet{TwitterTweetID: math.MaxInt64, Tweet: tweet280,
+		RawJSON: `{"id":18446744073709551615,"text":"tweet"}`, MediaPaths: "media/ab/ab.jpg,media/cd/cd.mp4",
+		PostedAt: postedAt, TwitterUserID: math.MaxInt64}
+	insertID, err := s.service.Insert(et)
+	s.NoError(err)
+	s.Equal(uint64(1), insertID)
+
+	rows, err := sq.Select("id", "COALESCE(run_id, 0)", "twitter_tweet_id", "tweet", "raw_json", "media_paths",
+		"posted_at", "twitter_user_id", "updated_at", "created_at").
+		From(model.EraseTweetTableName).RunWith(s.db).Query()
+	s.NoError(err)
+
+	var cnt int
+	for rows.Next() {
+		var actual model.EraseTweet
+		err := rows.Scan(&actual.ID, &actual.RunID, &actual.TwitterTweetID, &actual.Tweet,
+			&actual.RawJSON, &actual.MediaPaths, &actual.PostedAt, &actual.TwitterUserID, &actual.UpdatedAt, &actual.CreatedAt)
+		s.NoError(err)
+
+		s.Equal(insertID, actual.ID)
+		s.Equal(uint64(0), actual.RunID)
+		s.Equal(et.TwitterTweetID, actual.TwitterTweetID)
+		s.Equal(et.Tweet, actual.Tweet)
+		s.Equal(et.RawJSON, actual.RawJSON)
+		s.Equal(et.MediaPaths, actual.MediaPaths)
+		s.WithinDuration(et.PostedAt.Truncate(time.Microsecond), actual.PostedAt, 0)
+		s.Equal(et.TwitterUserID, actual.TwitterUserID)
+
+		threeSecAgo := time.Now().UTC().Add(-3 * time.Second)
+		s.True(actual.UpdatedAt.After(threeSecAgo))
+		s.True(actual.CreatedAt.After(threeSecAgo))
+
+		cnt++
+	}
+
+	s.Equal(1, cnt)
+	s.NoError(rows.Err())
+	s.NoError(rows.Close())
+
+	// Duplicate tweet.
+	et.MediaPaths = ""
+	dupInsertID, err := s.service.Insert(et)
+	s.NoError(err)
+	s.Equal(insertID, dupInsertID)
+
+	var mediaPaths string
+	err = sq.Select("COUNT(*)", "MAX(media_paths)").From(model.EraseTweetTableName).
+		RunWith(s.db).QueryRow().Scan(&cnt, &mediaPaths)
+	s.NoError(err)
+	s.Equal(1, cnt)
+	s.Equal(et.MediaPaths, mediaPaths)
+
+	// Not exist user.
+	insertID, err = s.service.Insert(&model.EraseTweet{TwitterUserID: 3})
+	s.Error(err)
+	s.Equal(uint64(0), insertID)
+}
+
+func (s *eraseTweetTestSuite) TestBulkInsert() {
+	userID := uint64(1)
+	cnt := 2500
+	ets := make([]*model.EraseTweet, cnt)
+	for i := 0; i < cnt; i++ {
+		ets[i] = &model.EraseTweet{TwitterTweetID: uint64(i + 1), Tweet: "tweet",
+			PostedAt: time.Now().UTC(), TwitterUserID: userID}
+	}
+
+	err := s.service.BulkInsert(ets)
+	s.NoError(err)
+
+	tweetIDs, err := s.service.AlreadyEraseTweetIDs(userID, []uint64{1, uint64(cnt), uint64(cnt + 1)})
+	s.NoError(err)
+	s.Len(tweetIDs, 2)
+
+	// Duplicate tweets are updated.
+	err = s.service.BulkInsert([]*model.EraseTweet{ets[0], ets[0], ets[1]})
+	s.NoError(err)
+
+	var rowCnt int
+	err = sq.Select("COUNT(*)").From(model.EraseTweetTableName).RunWith(s.db).QueryRow().Scan(&rowCnt)
+	s.NoError(err)
+	s.Equal(cnt, rowCnt)
+
+	// Not exist user.
+	err = s.service.BulkInsert([]*model.EraseTweet{{TwitterUserID: 3}})
+	s.Error(err)
+}
+
+func (s *eraseTweetTestSuite) TestCountByPostedMonth() {
+	userID := uint64(1)
+	ets := []*model.EraseTweet{
+		{TwitterTweetID: 1, PostedAt: time.Date(2016, 12, 31, 23, 59, 59, 0, time.UTC), TwitterUserID: userID},
+		{TwitterTweetID: 2, PostedAt: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), TwitterUserID: userID},
+		{TwitterTweetID: 3, PostedAt: time.Date(2017, 1, 31, 0, 0, 0, 0, time.UTC), TwitterUserID: userID},
+		{TwitterTweetID: 4, PostedAt: time.Date(2017, 1, 31, 0, 0, 0, 0, time.UTC), TwitterUserID: 2},
+	}
+	err := s.service.BulkInsert(ets)
+	s.NoError(err)
+
+	// Duplicate tweet is counted once.
+	_, err = s.service.Insert(ets[2])
+	s.NoError(err)
+
+	kcs, err := s.service.CountByPostedMonth(userID)
+	s.NoError(err)
+	s.Equal([]*model.KeyCount{{Key: "2016-12", Count: 1}, {Key: "2017-01", Count: 2}}, kcs)
+
+	// Not exist.
+	kcs, err = s.service.CountByPostedMonth(math.MaxInt64)
+	s.NoError(err)
+	s.Len(kcs, 0)
+}
+
+func (s *eraseTweetTestSuite) TestCountBySource() {
+	userID := uint64(1)
+	web := `<a href="http://twitter.com" rel="nofollow">Twitter Web Client</a>`
+	ets := []*model.EraseTweet{
+		{TwitterTweetID: 1, RawJSON: `{"source":"` + strings.Replace(web, `"`, `\"`, -1) + `"}`,
+			PostedAt: time.Now().UTC(), TwitterUserID: userID},
+		{TwitterTweetID: 2, RawJSON: `{"text":"no source"}`, PostedAt: time.Now().UTC(), TwitterUserID: userID},
+		{TwitterTweetID: 3, PostedAt: time.Now().UTC(), TwitterUserID: userID},
+		{TwitterTweetID: 4, RawJSON: `{"source":"web"}`, PostedAt: time.Now().UTC(), TwitterUserID: 2},
+	}
+	err := s.service.BulkInsert(ets)
+	s.NoError(err)
+
+	kcs, err := s.service.CountBySource(userID)
+	s.NoError(err)
+	s.Equal([]*model.KeyCount{{Key: "", Count: 2}, {Key: web, Count: 1}}, kcs)
+}
+
+func (s *eraseTweetTestSuite) TestEraseTweets() {
+	userID := uint64(1)
+	postedAt := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	ets := []*model.EraseTweet{
+		{TwitterTweetID: 3, Tweet: "third", PostedAt: postedAt.Add(time.Hour), TwitterUserID: userID},
+		{TwitterTweetID: 2, Tweet: "second", RawJSON: `{"id":2}`, MediaPaths: "media/ab/ab.jpg",
+			PostedAt: postedAt, TwitterUserID: userID},
+		{TwitterTweetID: 1, Tweet: "first", PostedAt: postedAt, TwitterUserID: userID},
+		{TwitterTweetID: 4, Tweet: "other user", PostedAt: postedAt, TwitterUserID: 2},
+	}
+	err := s.service.BulkInsert(ets)
+	s.NoError(err)
+
+	// Duplicate tweet is updated.
+	ets[0].Tweet = "updated"
+	_, err = s.service.Insert(ets[0])
+	s.NoError(err)
+
+	actual, err := s.service.EraseTweets(userID)
+	s.NoError(err)
+	s.Len(actual, 3)
+
+	for i, expected := range []*model.EraseTweet{ets[2], ets[1], ets[0]} {
+		s.Equal(expected.TwitterTweetID, actual[i].TwitterTweetID)
+		s.Equal(expected.Tweet, actual[i].Tweet)
+		s.Equal(expected.RawJSON, actual[i].RawJSON)
+		s.Equal(expected.MediaPaths, actual[i].MediaPaths)
+		s.WithinDuration(expected.PostedAt, actual[i].PostedAt, 0)
+		s.Equal(userID, actual[i].TwitterUserID)
+		s.NotZero(actual[i].ID)
+		s.False(actual[i].CreatedAt.IsZero())
+	}
+
+	// Not exist.
+	actual, err = s.service.EraseTweets(math.MaxInt64)
+	s.NoError(err)
+	s.Len(actual, 0)
+}
+
+func (s *eraseTweetTestSuite) TestScrubContent() {
+	userID := uint64(1)
+	ets := []*model.EraseTweet{
+		{TwitterTweetID: 1, Tweet: "secret", RawJSON: `{"text":"secret"}`, PostedAt: time.Now().UTC(), TwitterUserID: userID},
+		{TwitterTweetID: 2, Tweet: "kept", PostedAt: time.Now().UTC(), TwitterUserID: userID},
+	}
+	err := s.service.BulkInsert(ets)
+	s.NoError(err)
+
+	erased, err := s.service.EraseTweets(userID)
+	s.NoError(err)
+	s.Len(erased, 2)
+
+	scrubbed := erased[0]
+	if scrubbed.TwitterTweetID != 1 {
+		scrubbed = erased[1]
+	}
+
+	id := scrubbed.ID
+	scrubbed.StoreContent(model.StoreContentHash, "salt")
+	err = s.service.ScrubContent([]*model.EraseTweet{scrubbed})
+	s.NoError(err)
+
+	actual, err := s.service.EraseTweets(userID)
+	s.NoError(err)
+	s.Len(actual, 2)
+
+	for _, et := range actual {
+		if et.TwitterTweetID != 1 {
+			s.Equal("kept", et.Tweet)
+			s.Equal("", et.TweetHash)
+			continue
+		}
+
+		s.Equal(id, et.ID)
+		s.Equal("", et.Tweet)
+		s.Equal("", et.RawJSON)
+		s.Equal(model.TweetHash("salt", "secret"), et.TweetHash)
+	}
+}
+
+func (s *eraseTweetTestSuite) TearDownSuite() {
+	s.db.Close()
+}
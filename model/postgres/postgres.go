@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"database/sql"
+	"net/url"
+
+	"github.com/178inaba/tweeraser/config"
+	"github.com/178inaba/tweeraser/model"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+)
+
+// errUndefinedTable is the postgres error code of the table does not exist.
+const errUndefinedTable = "42P01"
+
+// bulkInsertRowCnt is the number of rows inserted by one statement.
+const bulkInsertRowCnt = 1000
+
+// psql is the statement builder with postgres placeholders ($1, $2, ...).
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+// sslModes is the sslmode of the tls setting.
+var sslModes = map[string]string{
+	"":            "prefer",
+	"preferred":   "prefer",
+	"false":       "disable",
+	"true":        "verify-full",
+	"skip-verify": "require",
+}
+
+// Open is open postgres connection from [database] config.
+func Open(conf config.Database) (*sql.DB, error) {
+	if conf.DSN != "" {
+		return sql.Open("postgres", conf.DSN)
+	}
+
+	password, err := conf.ReadPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	host := conf.Host
+	if host == "" {
+		host = "localhost"
+	}
+
+	v := url.Values{}
+	v.Set("sslmode", sslModes[conf.TLS])
+	if conf.TLSCAFile != "" {
+		v.Set("sslrootcert", conf.TLSCAFile)
+	}
+
+	if conf.TLSCertFile != "" {
+		v.Set("sslcert", conf.TLSCertFile)
+		v.Set("sslkey", conf.TLSKeyFile)
+	}
+
+	u := &url.URL{Scheme: "postgres", User: url.UserPassword(conf.User, password),
+		Host: host, Path: "/" + conf.Name, RawQuery: v.Encode()}
+	return sql.Open("postgres", u.String())
+}
+
+// SetMaxOpenConnsFromDB set max open connections from database configuration.
+// Can specify the percentage.
+// If 0 is specified for percentage, 0 is set.
+func SetMaxOpenConnsFromDB(db *sql.DB, percentage int) error {
+	// Get database max conns.
+	var maxConns int
+	err := db.QueryRow("SELECT current_setting('max_connections')::int").Scan(&maxConns)
+	if err != nil {
+		return err
+	}
+
+	// Set.
+	db.SetMaxOpenConns(maxConns * percentage / 100)
+	return nil
+}
+
+func isUndefinedTable(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == errUndefinedTable
+}
+
+// nullableID returns nil for the id 0 to store NULL into the nullable foreign key.
+func nullableID(id uint64) interface{} {
+	if id == 0 {
+		return nil
+	}
+
+	return id
+}
+
+// queryKeyCounts runs the query that selects the key and the count of the group.
+func queryKeyCounts(pr prepareRunner, query string, args []interface{}) ([]*model.KeyCount, error) {
+	rows, err := pr.Query(query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var kcs []*model.KeyCount
+	for rows.Next() {
+		var kc model.KeyCount
+		err := rows.Scan(&kc.Key, &kc.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		kcs = append(kcs, &kc)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return kcs, nil
+}
+
+type prepareRunner struct {
+	preparer sq.Preparer
+	canClose bool
+}
+
+func newPrepareRunner(preparer sq.Preparer) prepareRunner {
+	canClose := false
+	if _, ok := preparer.(*sql.DB); ok {
+		canClose = true
+	}
+
+	return prepareRunner{preparer: preparer, canClose: canClose}
+}
+
+func (r prepareRunner) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := r.preparer.Prepare(query)
+	if err != nil {
+		return nil, err
+	} else if r.canClose {
+		defer stmt.Close()
+	}
+
+	return stmt.Query(args...)
+}
+
+func (r prepareRunner) Exec(query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := r.preparer.Prepare(query)
+	if err != nil {
+		return nil, err
+	} else if r.canClose {
+		defer stmt.Close()
+	}
+
+	return stmt.Exec(args...)
+}
+
+func (r prepareRunner) QueryRow(query string, args ...interface{}) sq.RowScanner {
+	stmt, err := r.preparer.Prepare(query)
+	if err != nil {
+		return &row{err: err}
+	} else if r.canClose {
+		defer stmt.Close()
+	}
+
+	return &row{RowScanner: stmt.QueryRow(args...)}
+}
+
+type row struct {
+	sq.RowScanner
+	err error
+}
+
+func (r *row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	return r.RowScanner.Scan(dest...)
+}
@@ -0,0 +1,56 @@
+package postgres_test
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/178inaba/tweeraser/config"
+	"github.com/178inaba/tweeraser/model/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDSNEnv is the environment variable of the test db dsn.
+// PostgreSQL is optional, so the tests are skipped without it.
+const testDSNEnv = "TWEERASER_TEST_POSTGRES_DSN"
+
+func openTestDB() (*sql.DB, error) {
+	return postgres.Open(config.Database{DSN: os.Getenv(testDSNEnv)})
+}
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testDSNEnv) == "" {
+		fmt.Fprintf(os.Stderr, "Skip postgres tests: %s is not set.\n", testDSNEnv)
+		os.Exit(0)
+	}
+
+	// Migrate test db to the latest before all suites.
+	db, err := openTestDB()
+	if err == nil {
+		_, err = postgres.NewSchemaMigrationService(db).Up()
+		db.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Fail migrate test db: %s\n", err)
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestOpen(t *testing.T) {
+	// Not exist password file.
+	db, err := postgres.Open(config.Database{User: "postgres", PasswordFile: "path/nothing"})
+	assert.Error(t, err)
+	assert.Nil(t, db)
+}
+
+func TestSetMaxOpenConnsFromDB(t *testing.T) {
+	db, err := openTestDB()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	err = postgres.SetMaxOpenConnsFromDB(db, 90)
+	assert.NoError(t, err)
+}
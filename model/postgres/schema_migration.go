@@ -0,0 +1,308 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+// migration is one version of the schema.
+type migration struct {
+	version uint64
+	name    string
+	up      []string
+	down    []string
+}
+
+// migrations must be appended with the next version. Never edit the applied migration.
+// PostgreSQL backend has only erase tweet, erase error and twitter user tables.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create_tables",
+		up: []string{
+			`CREATE TABLE twitter_users (
+			  user_id BIGINT NOT NULL,
+			  screen_name VARCHAR(15) NOT NULL,
+			  name VARCHAR(50) NOT NULL,
+			  lang VARCHAR(100) NOT NULL,
+			  updated_at TIMESTAMP NOT NULL,
+			  created_at TIMESTAMP NOT NULL,
+			  PRIMARY KEY (user_id)
+			)`,
+			`CREATE TABLE erase_tweets (
+			  id BIGSERIAL NOT NULL PRIMARY KEY,
+			  twitter_tweet_id BIGINT NOT NULL,
+			  tweet TEXT NOT NULL,
+			  raw_json TEXT NOT NULL,
+			  media_paths TEXT NOT NULL,
+			  posted_at TIMESTAMP NOT NULL,
+			  twitter_user_id BIGINT NOT NULL REFERENCES twitter_users (user_id),
+			  updated_at TIMESTAMP NOT NULL,
+			  created_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE TABLE erase_errors (
+			  id BIGSERIAL NOT NULL PRIMARY KEY,
+			  tried_twitter_user_id BIGINT NOT NULL,
+			  twitter_tweet_id BIGINT NOT NULL,
+			  status_code SMALLINT NOT NULL,
+			  error_message TEXT NOT NULL,
+			  updated_at TIMESTAMP NOT NULL,
+			  created_at TIMESTAMP NOT NULL
+			)`,
+		},
+		down: []string{
+			`DROP TABLE erase_errors`,
+			`DROP TABLE erase_tweets`,
+			`DROP TABLE twitter_users`,
+		},
+	},
+	{
+		version: 2,
+		name:    "unique_erase_tweets_and_collapse_erase_errors",
+		// The last error row of each tweet is kept with the count of the collapsed rows.
+		up: []string{
+			`DELETE FROM erase_tweets e USING erase_tweets e2 WHERE e.twitter_user_id = e2.twitter_user_id
+			  AND e.twitter_tweet_id = e2.twitter_tweet_id AND e.id > e2.id`,
+			`ALTER TABLE erase_tweets ADD CONSTRAINT erase_tweets_twitter_user_id_twitter_tweet_id_key
+			  UNIQUE (twitter_user_id, twitter_tweet_id)`,
+			`ALTER TABLE erase_errors ADD attempts INTEGER NOT NULL DEFAULT 1,
+			  ADD first_error_at TIMESTAMP, ADD last_error_at TIMESTAMP`,
+			`UPDATE erase_errors e SET attempts = g.attempts, first_error_at = g.first_error_at,
+			  last_error_at = e.created_at, created_at = g.first_error_at
+			  FROM (
+			    SELECT MAX(id) AS id, COUNT(*) AS attempts, MIN(created_at) AS first_error_at
+			    FROM erase_errors GROUP BY tried_twitter_user_id, twitter_tweet_id
+			  ) g WHERE e.id = g.id`,
+			`DELETE FROM erase_errors WHERE first_error_at IS NULL`,
+			`ALTER TABLE erase_errors ALTER attempts DROP DEFAULT,
+			  ALTER first_error_at SET NOT NULL, ALTER last_error_at SET NOT NULL,
+			  ADD CONSTRAINT erase_errors_tried_twitter_user_id_twitter_tweet_id_key
+			  UNIQUE (tried_twitter_user_id, twitter_tweet_id)`,
+		},
+		// The collapsed error rows are not restored.
+		down: []string{
+			`ALTER TABLE erase_errors DROP CONSTRAINT erase_errors_tried_twitter_user_id_twitter_tweet_id_key,
+			  DROP attempts, DROP first_error_at, DROP last_error_at`,
+			`ALTER TABLE erase_tweets DROP CONSTRAINT erase_tweets_twitter_user_id_twitter_tweet_id_key`,
+		},
+	},
+	{
+		version: 3,
+		name:    "create_erase_runs",
+		up: []string{
+			`CREATE TABLE erase_runs (
+			  id BIGSERIAL NOT NULL PRIMARY KEY,
+			  twitter_user_id BIGINT NOT NULL REFERENCES twitter_users (user_id),
+			  command VARCHAR(64) NOT NULL,
+			  sources VARCHAR(255) NOT NULL,
+			  filters TEXT NOT NULL,
+			  options TEXT NOT NULL,
+			  host VARCHAR(255) NOT NULL,
+			  tool_version VARCHAR(64) NOT NULL,
+			  target_count BIGINT NOT NULL,
+			  erased_count BIGINT NOT NULL,
+			  error_count BIGINT NOT NULL,
+			  started_at TIMESTAMP NOT NULL,
+			  ended_at TIMESTAMP NULL,
+			  updated_at TIMESTAMP NOT NULL,
+			  created_at TIMESTAMP NOT NULL
+			)`,
+			`ALTER TABLE erase_tweets ADD run_id BIGINT NULL REFERENCES erase_runs (id)`,
+			`ALTER TABLE erase_errors ADD run_id BIGINT NULL REFERENCES erase_runs (id)`,
+		},
+		down: []string{
+			`ALTER TABLE erase_errors DROP run_id`,
+			`ALTER TABLE erase_tweets DROP run_id`,
+			`DROP TABLE erase_runs`,
+		},
+	},
+	{
+		version: 4,
+		name:    "add_erase_tweets_tweet_hash",
+		up: []string{
+			`ALTER TABLE erase_tweets ADD tweet_hash VARCHAR(64) NOT NULL DEFAULT ''`,
+		},
+		down: []string{
+			`ALTER TABLE erase_tweets DROP tweet_hash`,
+		},
+	},
+}
+
+// SchemaMigrationService is schema migrations table service.
+type SchemaMigrationService struct {
+	db *sql.DB
+	pr prepareRunner
+}
+
+// NewSchemaMigrationService is create schema migration service.
+func NewSchemaMigrationService(db *sql.DB) SchemaMigrationService {
+	return SchemaMigrationService{db: db, pr: newPrepareRunner(db)}
+}
+
+// Version returns the latest applied version. It is 0 if no migration is applied.
+func (s SchemaMigrationService) Version() (uint64, error) {
+	query, args, err := psql.Select("COALESCE(MAX(version), 0)").From(model.SchemaMigrationTableName).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var version uint64
+	err = s.pr.QueryRow(query, args...).Scan(&version)
+	if isUndefinedTable(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// LatestVersion returns the version which this program expects.
+func (s SchemaMigrationService) LatestVersion() uint64 {
+	return migrations[len(migrations)-1].version
+}
+
+// Statuses returns all migrations with the applied time.
+func (s SchemaMigrationService) Statuses() ([]*model.SchemaMigration, error) {
+	appliedAts, err := s.appliedAts()
+	if err != nil {
+		return nil, err
+	}
+
+	sms := make([]*model.SchemaMigration, len(migrations))
+	for i, m := range migrations {
+		sms[i] = &model.SchemaMigration{Version: m.version, Name: m.name, AppliedAt: appliedAts[m.version]}
+	}
+
+	return sms, nil
+}
+
+func (s SchemaMigrationService) appliedAts() (map[uint64]time.Time, error) {
+	query, args, err := psql.Select("version", "applied_at").From(model.SchemaMigrationTableName).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	appliedAts := map[uint64]time.Time{}
+	rows, err := s.pr.Query(query, args...)
+	if isUndefinedTable(err) {
+		return appliedAts, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version uint64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		appliedAts[version] = appliedAt
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return appliedAts, nil
+}
+
+// Up applies all pending migrations in order and returns the applied migrations.
+// Each migration is applied in a transaction.
+func (s SchemaMigrationService) Up() ([]*model.SchemaMigration, error) {
+	_, err := s.db.Exec("CREATE TABLE IF NOT EXISTS " + model.SchemaMigrationTableName + ` (
+  version BIGINT NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  applied_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (version)
+)`)
+	if err != nil {
+		return nil, err
+	}
+
+	appliedAts, err := s.appliedAts()
+	if err != nil {
+		return nil, err
+	}
+
+	var sms []*model.SchemaMigration
+	for _, m := range migrations {
+		if _, ok := appliedAts[m.version]; ok {
+			continue
+		}
+
+		sm := &model.SchemaMigration{Version: m.version, Name: m.name, AppliedAt: time.Now().UTC()}
+		err := s.transact(m.up, psql.Insert(model.SchemaMigrationTableName).
+			Columns("version", "name", "applied_at").Values(sm.Version, sm.Name, sm.AppliedAt))
+		if err != nil {
+			return sms, err
+		}
+
+		sms = append(sms, sm)
+	}
+
+	return sms, nil
+}
+
+// Down rolls back the latest applied migration and returns it.
+// It returns nil if no migration is applied.
+func (s SchemaMigrationService) Down() (*model.SchemaMigration, error) {
+	version, err := s.Version()
+	if err != nil {
+		return nil, err
+	} else if version == 0 {
+		return nil, nil
+	}
+
+	for _, m := range migrations {
+		if m.version != version {
+			continue
+		}
+
+		err := s.transact(m.down, psql.Delete(model.SchemaMigrationTableName).Where(sq.Eq{"version": version}))
+		if err != nil {
+			return nil, err
+		}
+
+		return &model.SchemaMigration{Version: m.version, Name: m.name}, nil
+	}
+
+	return nil, errors.Errorf("Migration version %d is unknown.", version)
+}
+
+// transact executes the statements and records the migration in a transaction.
+func (s SchemaMigrationService) transact(stmts []string, record sq.Sqlizer) (err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err == nil {
+			err = tx.Commit()
+		} else if rErr := tx.Rollback(); rErr != nil {
+			err = errors.Wrap(err, rErr.Error())
+		}
+	}()
+
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	query, args, err := record.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(query, args...)
+	return err
+}
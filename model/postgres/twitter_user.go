@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	sq "github.com/Masterminds/squirrel"
+)
+
+// TwitterUserService is twitter user table service.
+type TwitterUserService struct {
+	pr prepareRunner
+}
+
+// NewTwitterUserService is create twitter user service.
+func NewTwitterUserService(preparer sq.Preparer) TwitterUserService {
+	return TwitterUserService{pr: newPrepareRunner(preparer)}
+}
+
+// InsertUpdate inserts if there is no line corresponding to the primary key, and updates if it does.
+// Like model.TwitterUser.CheckWantUpdate, the row is updated only when ScreenName, Name or Lang differs.
+func (s TwitterUserService) InsertUpdate(tu *model.TwitterUser) error {
+	now := time.Now().UTC()
+	query, args, err := psql.Insert(model.TwitterUserTableName).Columns(
+		"user_id", "screen_name", "name", "lang", "updated_at", "created_at").
+		Values(tu.UserID, tu.ScreenName, tu.Name, tu.Lang, now, now).
+		Suffix("ON CONFLICT (user_id) DO UPDATE SET " +
+			"screen_name = EXCLUDED.screen_name, name = EXCLUDED.name, " +
+			"lang = EXCLUDED.lang, updated_at = EXCLUDED.updated_at " +
+			"WHERE (twitter_users.screen_name, twitter_users.name, twitter_users.lang) " +
+			"IS DISTINCT FROM (EXCLUDED.screen_name, EXCLUDED.name, EXCLUDED.lang)").ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.pr.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// TwitterUsers returns the stored twitter users in order of the user id.
+func (s TwitterUserService) TwitterUsers() ([]*model.TwitterUser, error) {
+	query, args, err := psql.Select("user_id", "screen_name", "name", "lang", "updated_at", "created_at").
+		From(model.TwitterUserTableName).OrderBy("user_id").ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pr.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tus []*model.TwitterUser
+	for rows.Next() {
+		var tu model.TwitterUser
+		err := rows.Scan(&tu.UserID, &tu.ScreenName, &tu.Name, &tu.Lang, &tu.UpdatedAt, &tu.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		tus = append(tus, &tu)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return tus, nil
+}
@@ -0,0 +1,118 @@
+package postgres_test
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/postgres"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/suite"
+)
+
+type twitterUserSuite struct {
+	suite.Suite
+
+	db      *sql.DB
+	service model.TwitterUserService
+}
+
+func TestTwitterUserSuite(t *testing.T) {
+	suite.Run(t, new(twitterUserSuite))
+}
+
+func (s *twitterUserSuite) SetupSuite() {
+	db, err := openTestDB()
+	s.NoError(err)
+
+	s.db = db
+	s.service = postgres.NewTwitterUserService(db)
+}
+
+func (s *twitterUserSuite) SetupTest() {
+	// Reset test db.
+	_, err := s.db.Exec(fmt.Sprintf("TRUNCATE TABLE %s RESTART IDENTITY CASCADE", model.TwitterUserTableName))
+	s.NoError(err)
+}
+
+func (s *twitterUserSuite) TestInsertUpdate() {
+	tu := &model.TwitterUser{UserID: math.MaxInt64,
+		ScreenName: "screen_name", Name: "name🍣", Lang: "en"}
+	err := s.service.InsertUpdate(tu)
+	s.NoError(err)
+
+	rows, err := sq.Select("*").
+		From(model.TwitterUserTableName).RunWith(s.db).Query()
+	s.NoError(err)
+
+	var cnt int
+	for rows.Next() {
+		var actual model.TwitterUser
+		err := rows.Scan(&actual.UserID, &actual.ScreenName,
+			&actual.Name, &actual.Lang, &actual.UpdatedAt, &actual.CreatedAt)
+		s.NoError(err)
+
+		s.Equal(tu.UserID, actual.UserID)
+		s.Equal(tu.ScreenName, actual.ScreenName)
+		s.Equal(tu.Name, actual.Name)
+		s.Equal(tu.Lang, actual.Lang)
+
+		threeSecAgo := time.Now().UTC().Add(-3 * time.Second)
+		s.True(actual.UpdatedAt.After(threeSecAgo))
+		s.True(actual.CreatedAt.After(threeSecAgo))
+
+		cnt++
+	}
+
+	s.Equal(1, cnt)
+	s.NoError(rows.Err())
+	s.NoError(rows.Close())
+
+	// Duplicate update.
+	tu = &model.TwitterUser{UserID: math.MaxInt64, Name: "name_dup"}
+	err = s.service.InsertUpdate(tu)
+	s.NoError(err)
+
+	rows, err = sq.Select("name").
+		From(model.TwitterUserTableName).RunWith(s.db).Query()
+	s.NoError(err)
+
+	cnt = 0
+	for rows.Next() {
+		var actual model.TwitterUser
+		err := rows.Scan(&actual.Name)
+		s.NoError(err)
+		s.Equal(tu.Name, actual.Name)
+		cnt++
+	}
+
+	s.Equal(1, cnt)
+	s.NoError(rows.Err())
+	s.NoError(rows.Close())
+}
+
+func (s *twitterUserSuite) TestTwitterUsers() {
+	tus, err := s.service.TwitterUsers()
+	s.NoError(err)
+	s.Empty(tus)
+
+	s.NoError(s.service.InsertUpdate(&model.TwitterUser{UserID: 2, ScreenName: "second", Name: "name2", Lang: "ja"}))
+	s.NoError(s.service.InsertUpdate(&model.TwitterUser{UserID: 1, ScreenName: "first", Name: "name1", Lang: "en"}))
+
+	tus, err = s.service.TwitterUsers()
+	s.NoError(err)
+	s.Len(tus, 2)
+	s.Equal(uint64(1), tus[0].UserID)
+	s.Equal("first", tus[0].ScreenName)
+	s.Equal("name1", tus[0].Name)
+	s.Equal("en", tus[0].Lang)
+	s.Equal(uint64(2), tus[1].UserID)
+	s.Equal("second", tus[1].ScreenName)
+}
+
+func (s *twitterUserSuite) TearDownSuite() {
+	s.db.Close()
+}
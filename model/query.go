@@ -0,0 +1,6 @@
+package model
+
+// QueryService is service interface to run user written queries.
+type QueryService interface {
+	QueryTweetIDs(query string) ([]uint64, error)
+}
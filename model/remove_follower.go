@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// RemoveFollowerTableName is remove follower table name.
+const RemoveFollowerTableName = "remove_followers"
+
+// Remove follower statuses.
+// The row is pending unblock from before the block until the unblock succeeds.
+const (
+	RemoveFollowerStatusPendingUnblock = "pending_unblock"
+	RemoveFollowerStatusRemoved        = "removed"
+)
+
+// RemoveFollower is removed follower object.
+type RemoveFollower struct {
+	ID                    uint64
+	TwitterUserID         uint64
+	FollowerTwitterUserID uint64
+	FollowerScreenName    string
+	Status                string
+	UpdatedAt             time.Time
+	CreatedAt             time.Time
+}
+
+// RemoveFollowerService is remove follower service interface.
+type RemoveFollowerService interface {
+	PendingUnblocks(userID uint64) ([]*RemoveFollower, error)
+	Insert(rf *RemoveFollower) (uint64, error)
+	UpdateStatus(id uint64, status string) error
+	Delete(id uint64) error
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// SchemaMigrationTableName is schema migration table name.
+const SchemaMigrationTableName = "schema_migrations"
+
+// SchemaMigration is schema migration object.
+// AppliedAt is zero value if the migration is not applied yet.
+type SchemaMigration struct {
+	Version   uint64
+	Name      string
+	AppliedAt time.Time
+}
+
+// Applied reports whether the migration is applied.
+func (m *SchemaMigration) Applied() bool {
+	return !m.AppliedAt.IsZero()
+}
+
+// SchemaMigrationService is schema migration service interface.
+type SchemaMigrationService interface {
+	Version() (uint64, error)
+	LatestVersion() uint64
+	Statuses() ([]*SchemaMigration, error)
+	Up() ([]*SchemaMigration, error)
+	Down() (*SchemaMigration, error)
+}
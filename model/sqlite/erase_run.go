@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+// EraseRunService is erase runs table service.
+type EraseRunService struct {
+	pr prepareRunner
+}
+
+// NewEraseRunService is create erase run service.
+func NewEraseRunService(db *sql.DB) EraseRunService {
+	return EraseRunService{pr: newPrepareRunner(db)}
+}
+
+// Insert is insert to erase run table.
+func (s EraseRunService) Insert(er *model.EraseRun) (uint64, error) {
+	now := time.Now().UTC()
+	query, args, err := sq.Insert(model.EraseRunTableName).Columns(
+		"twitter_user_id", "command", "sources", "filters", "options", "host", "tool_version",
+		"target_count", "erased_count", "error_count", "started_at", "ended_at", "updated_at", "created_at").
+		Values(er.TwitterUserID, er.Command, er.Sources, er.Filters, er.Options, er.Host, er.ToolVersion,
+			er.TargetCount, er.ErasedCount, er.ErrorCount, er.StartedAt, er.EndedAt, now, now).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := s.pr.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	lastInsertID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastInsertID), nil
+}
+
+// Update is update the result counts and the ended time of the erase run.
+func (s EraseRunService) Update(er *model.EraseRun) error {
+	setMap := map[string]interface{}{"target_count": er.TargetCount, "erased_count": er.ErasedCount,
+		"error_count": er.ErrorCount, "ended_at": er.EndedAt, "updated_at": time.Now().UTC()}
+	query, args, err := sq.Update(model.EraseRunTableName).
+		SetMap(setMap).Where(sq.Eq{"id": er.ID}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	res, err := s.pr.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	updateCnt, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if updateCnt < 1 {
+		return errors.Errorf("row not found: %d", er.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/sqlite"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/suite"
+)
+
+type eraseRunSuite struct {
+	suite.Suite
+
+	db      *testDB
+	service model.EraseRunService
+}
+
+func TestEraseRunSuite(t *testing.T) {
+	suite.Run(t, new(eraseRunSuite))
+}
+
+func (s *eraseRunSuite) SetupTest() {
+	// Create test db.
+	db, err := openTestDB()
+	s.Require().NoError(err)
+
+	s.db = db
+	s.service = sqlite.NewEraseRunService(db.DB)
+
+	// Create test twitter user.
+	err = sqlite.NewTwitterUserService(s.db.DB).InsertUpdate(&model.TwitterUser{UserID: 1})
+	s.NoError(err)
+}
+
+func (s *eraseRunSuite) TestInsertUpdate() {
+	startedAt := time.Now().UTC().Add(-time.Minute)
+	er := &model.EraseRun{TwitterUserID: 1, Command: "erase", Sources: "zip,timeline",
+		Filters: `{"query":""}`, Options: `{"backup_media":"media"}`, Host: "host",
+		ToolVersion: "v1.0.0", StartedAt: startedAt}
+	insertID, err := s.service.Insert(er)
+	s.NoError(err)
+	s.Equal(uint64(1), insertID)
+
+	var actual model.EraseRun
+	selectRun := func() error {
+		return sq.Select("id", "twitter_user_id", "command", "sources", "filters", "options", "host",
+			"tool_version", "target_count", "erased_count", "error_count", "started_at", "ended_at").
+			From(model.EraseRunTableName).RunWith(s.db.DB).QueryRow().Scan(&actual.ID, &actual.TwitterUserID,
+			&actual.Command, &actual.Sources, &actual.Filters, &actual.Options, &actual.Host, &actual.ToolVersion,
+			&actual.TargetCount, &actual.ErasedCount, &actual.ErrorCount, &actual.StartedAt, &actual.EndedAt)
+	}
+
+	err = selectRun()
+	s.NoError(err)
+	s.Equal(insertID, actual.ID)
+	s.Equal(er.TwitterUserID, actual.TwitterUserID)
+	s.Equal(er.Command, actual.Command)
+	s.Equal(er.Sources, actual.Sources)
+	s.Equal(er.Filters, actual.Filters)
+	s.Equal(er.Options, actual.Options)
+	s.Equal(er.Host, actual.Host)
+	s.Equal(er.ToolVersion, actual.ToolVersion)
+	s.WithinDuration(er.StartedAt, actual.StartedAt, 0)
+	s.Nil(actual.EndedAt)
+
+	// Finish.
+	endedAt := time.Now().UTC()
+	er.ID, er.TargetCount, er.ErasedCount, er.ErrorCount, er.EndedAt = insertID, 10, 8, 2, &endedAt
+	err = s.service.Update(er)
+	s.NoError(err)
+
+	err = selectRun()
+	s.NoError(err)
+	s.Equal(er.TargetCount, actual.TargetCount)
+	s.Equal(er.ErasedCount, actual.ErasedCount)
+	s.Equal(er.ErrorCount, actual.ErrorCount)
+	s.Require().NotNil(actual.EndedAt)
+	s.WithinDuration(endedAt, *actual.EndedAt, 0)
+
+	// Not exist run.
+	err = s.service.Update(&model.EraseRun{ID: insertID + 1})
+	s.Error(err)
+}
+
+func (s *eraseRunSuite) TestEraseTweetRunID() {
+	runID, err := s.service.Insert(&model.EraseRun{TwitterUserID: 1, StartedAt: time.Now().UTC()})
+	s.NoError(err)
+
+	ets := sqlite.NewEraseTweetService(s.db.DB)
+	_, err = ets.Insert(&model.EraseTweet{RunID: runID, TwitterTweetID: 1, TwitterUserID: 1})
+	s.NoError(err)
+
+	ees := sqlite.NewEraseErrorService(s.db.DB)
+	_, err = ees.Insert(&model.EraseError{RunID: runID, TriedTwitterUserID: 1, TwitterTweetID: 2})
+	s.NoError(err)
+
+	var tweetRunID, errorRunID uint64
+	err = sq.Select("run_id").From(model.EraseTweetTableName).RunWith(s.db.DB).QueryRow().Scan(&tweetRunID)
+	s.NoError(err)
+	s.Equal(runID, tweetRunID)
+
+	err = sq.Select("run_id").From(model.EraseErrorTableName).RunWith(s.db.DB).QueryRow().Scan(&errorRunID)
+	s.NoError(err)
+	s.Equal(runID, errorRunID)
+
+	// Not exist run.
+	_, err = ets.Insert(&model.EraseTweet{RunID: runID + 1, TwitterTweetID: 3, TwitterUserID: 1})
+	s.Error(err)
+}
+
+func (s *eraseRunSuite) TearDownTest() {
+	s.db.Close()
+}
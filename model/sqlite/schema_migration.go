@@ -0,0 +1,356 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/178inaba/tweeraser/model"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+// migration is one version of the schema.
+type migration struct {
+	version uint64
+	name    string
+	up      []string
+	down    []string
+}
+
+// migrations must be appended with the next version. Never edit the applied migration.
+// SQLite backend has only erase tweet, erase error and twitter user tables.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create_tables",
+		up: []string{
+			`CREATE TABLE twitter_users (
+			  user_id INTEGER NOT NULL,
+			  screen_name TEXT NOT NULL,
+			  name TEXT NOT NULL,
+			  lang TEXT NOT NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL,
+			  PRIMARY KEY (user_id)
+			)`,
+			`CREATE TABLE erase_tweets (
+			  id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			  twitter_tweet_id INTEGER NOT NULL,
+			  tweet TEXT NOT NULL,
+			  raw_json TEXT NOT NULL,
+			  media_paths TEXT NOT NULL,
+			  posted_at DATETIME NOT NULL,
+			  twitter_user_id INTEGER NOT NULL REFERENCES twitter_users (user_id),
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL
+			)`,
+			`CREATE TABLE erase_errors (
+			  id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			  tried_twitter_user_id INTEGER NOT NULL,
+			  twitter_tweet_id INTEGER NOT NULL,
+			  status_code INTEGER NOT NULL,
+			  error_message TEXT NOT NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL
+			)`,
+		},
+		down: []string{
+			`DROP TABLE erase_errors`,
+			`DROP TABLE erase_tweets`,
+			`DROP TABLE twitter_users`,
+		},
+	},
+	{
+		version: 2,
+		name:    "unique_erase_tweets_and_collapse_erase_errors",
+		// SQLite cannot add a not null column without a constant default, so erase_errors is rebuilt.
+		// The last error row of each tweet is kept with the count of the collapsed rows.
+		up: []string{
+			`DELETE FROM erase_tweets WHERE id NOT IN (
+			  SELECT MIN(id) FROM erase_tweets GROUP BY twitter_user_id, twitter_tweet_id
+			)`,
+			`CREATE UNIQUE INDEX erase_tweets_twitter_user_id_twitter_tweet_id
+			  ON erase_tweets (twitter_user_id, twitter_tweet_id)`,
+			`CREATE TABLE erase_errors_new (
+			  id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			  tried_twitter_user_id INTEGER NOT NULL,
+			  twitter_tweet_id INTEGER NOT NULL,
+			  attempts INTEGER NOT NULL,
+			  status_code INTEGER NOT NULL,
+			  error_message TEXT NOT NULL,
+			  first_error_at DATETIME NOT NULL,
+			  last_error_at DATETIME NOT NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL
+			)`,
+			`INSERT INTO erase_errors_new
+			  SELECT e.id, e.tried_twitter_user_id, e.twitter_tweet_id, g.attempts, e.status_code,
+			  e.error_message, g.first_error_at, e.created_at, e.updated_at, g.first_error_at
+			  FROM erase_errors e JOIN (
+			    SELECT MAX(id) AS id, COUNT(*) AS attempts, MIN(created_at) AS first_error_at
+			    FROM erase_errors GROUP BY tried_twitter_user_id, twitter_tweet_id
+			  ) g ON e.id = g.id`,
+			`DROP TABLE erase_errors`,
+			`ALTER TABLE erase_errors_new RENAME TO erase_errors`,
+			`CREATE UNIQUE INDEX erase_errors_tried_twitter_user_id_twitter_tweet_id
+			  ON erase_errors (tried_twitter_user_id, twitter_tweet_id)`,
+		},
+		// The collapsed error rows are not restored.
+		down: []string{
+			`DROP INDEX erase_errors_tried_twitter_user_id_twitter_tweet_id`,
+			`ALTER TABLE erase_errors DROP COLUMN attempts`,
+			`ALTER TABLE erase_errors DROP COLUMN first_error_at`,
+			`ALTER TABLE erase_errors DROP COLUMN last_error_at`,
+			`DROP INDEX erase_tweets_twitter_user_id_twitter_tweet_id`,
+		},
+	},
+	{
+		version: 3,
+		name:    "create_erase_runs",
+		up: []string{
+			`CREATE TABLE erase_runs (
+			  id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			  twitter_user_id INTEGER NOT NULL REFERENCES twitter_users (user_id),
+			  command TEXT NOT NULL,
+			  sources TEXT NOT NULL,
+			  filters TEXT NOT NULL,
+			  options TEXT NOT NULL,
+			  host TEXT NOT NULL,
+			  tool_version TEXT NOT NULL,
+			  target_count INTEGER NOT NULL,
+			  erased_count INTEGER NOT NULL,
+			  error_count INTEGER NOT NULL,
+			  started_at DATETIME NOT NULL,
+			  ended_at DATETIME NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL
+			)`,
+			`ALTER TABLE erase_tweets ADD run_id INTEGER NULL REFERENCES erase_runs (id)`,
+			`ALTER TABLE erase_errors ADD run_id INTEGER NULL REFERENCES erase_runs (id)`,
+		},
+		// SQLite cannot drop the foreign key column, so the tables are rebuilt without run_id.
+		down: []string{
+			`CREATE TABLE erase_tweets_old (
+			  id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			  twitter_tweet_id INTEGER NOT NULL,
+			  tweet TEXT NOT NULL,
+			  raw_json TEXT NOT NULL,
+			  media_paths TEXT NOT NULL,
+			  posted_at DATETIME NOT NULL,
+			  twitter_user_id INTEGER NOT NULL REFERENCES twitter_users (user_id),
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL
+			)`,
+			`INSERT INTO erase_tweets_old SELECT id, twitter_tweet_id, tweet, raw_json, media_paths,
+			  posted_at, twitter_user_id, updated_at, created_at FROM erase_tweets`,
+			`DROP TABLE erase_tweets`,
+			`ALTER TABLE erase_tweets_old RENAME TO erase_tweets`,
+			`CREATE UNIQUE INDEX erase_tweets_twitter_user_id_twitter_tweet_id
+			  ON erase_tweets (twitter_user_id, twitter_tweet_id)`,
+			`CREATE TABLE erase_errors_old (
+			  id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			  tried_twitter_user_id INTEGER NOT NULL,
+			  twitter_tweet_id INTEGER NOT NULL,
+			  attempts INTEGER NOT NULL,
+			  status_code INTEGER NOT NULL,
+			  error_message TEXT NOT NULL,
+			  first_error_at DATETIME NOT NULL,
+			  last_error_at DATETIME NOT NULL,
+			  updated_at DATETIME NOT NULL,
+			  created_at DATETIME NOT NULL
+			)`,
+			`INSERT INTO erase_errors_old SELECT id, tried_twitter_user_id, twitter_tweet_id, attempts, status_code,
+			  error_message, first_error_at, last_error_at, updated_at, created_at FROM erase_errors`,
+			`DROP TABLE erase_errors`,
+			`ALTER TABLE erase_errors_old RENAME TO erase_errors`,
+			`CREATE UNIQUE INDEX erase_errors_tried_twitter_user_id_twitter_tweet_id
+			  ON erase_errors (tried_twitter_user_id, twitter_tweet_id)`,
+			`DROP TABLE erase_runs`,
+		},
+	},
+	{
+		version: 4,
+		name:    "add_erase_tweets_tweet_hash",
+		up: []string{
+			`ALTER TABLE erase_tweets ADD tweet_hash TEXT NOT NULL DEFAULT ''`,
+		},
+		down: []string{
+			`ALTER TABLE erase_tweets DROP tweet_hash`,
+		},
+	},
+}
+
+// SchemaMigrationService is schema migrations table service.
+type SchemaMigrationService struct {
+	db *sql.DB
+	pr prepareRunner
+}
+
+// NewSchemaMigrationService is create schema migration service.
+func NewSchemaMigrationService(db *sql.DB) SchemaMigrationService {
+	return SchemaMigrationService{db: db, pr: newPrepareRunner(db)}
+}
+
+// Version returns the latest applied version. It is 0 if no migration is applied.
+func (s SchemaMigrationService) Version() (uint64, error) {
+	query, args, err := sq.Select("COALESCE(MAX(version), 0)").From(model.SchemaMigrationTableName).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var version uint64
+	err = s.pr.QueryRow(query, args...).Scan(&version)
+	if isNoSuchTable(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// LatestVersion returns the version which this program expects.
+func (s SchemaMigrationService) LatestVersion() uint64 {
+	return migrations[len(migrations)-1].version
+}
+
+// Statuses returns all migrations with the applied time.
+func (s SchemaMigrationService) Statuses() ([]*model.SchemaMigration, error) {
+	appliedAts, err := s.appliedAts()
+	if err != nil {
+		return nil, err
+	}
+
+	sms := make([]*model.SchemaMigration, len(migrations))
+	for i, m := range migrations {
+		sms[i] = &model.SchemaMigration{Version: m.version, Name: m.name, AppliedAt: appliedAts[m.version]}
+	}
+
+	return sms, nil
+}
+
+func (s SchemaMigrationService) appliedAts() (map[uint64]time.Time, error) {
+	query, args, err := sq.Select("version", "applied_at").From(model.SchemaMigrationTableName).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	appliedAts := map[uint64]time.Time{}
+	rows, err := s.pr.Query(query, args...)
+	if isNoSuchTable(err) {
+		return appliedAts, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version uint64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		appliedAts[version] = appliedAt
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return appliedAts, nil
+}
+
+// Up applies all pending migrations in order and returns the applied migrations.
+// Each migration is applied in a transaction.
+func (s SchemaMigrationService) Up() ([]*model.SchemaMigration, error) {
+	_, err := s.db.Exec("CREATE TABLE IF NOT EXISTS " + model.SchemaMigrationTableName + ` (
+  version INTEGER NOT NULL,
+  name TEXT NOT NULL,
+  applied_at DATETIME NOT NULL,
+  PRIMARY KEY (version)
+)`)
+	if err != nil {
+		return nil, err
+	}
+
+	appliedAts, err := s.appliedAts()
+	if err != nil {
+		return nil, err
+	}
+
+	var sms []*model.SchemaMigration
+	for _, m := range migrations {
+		if _, ok := appliedAts[m.version]; ok {
+			continue
+		}
+
+		sm := &model.SchemaMigration{Version: m.version, Name: m.name, AppliedAt: time.Now().UTC()}
+		err := s.transact(m.up, sq.Insert(model.SchemaMigrationTableName).
+			Columns("version", "name", "applied_at").Values(sm.Version, sm.Name, sm.AppliedAt))
+		if err != nil {
+			return sms, err
+		}
+
+		sms = append(sms, sm)
+	}
+
+	return sms, nil
+}
+
+// Down rolls back the latest applied migration and returns it.
+// It returns nil if no migration is applied.
+func (s SchemaMigrationService) Down() (*model.SchemaMigration, error) {
+	version, err := s.Version()
+	if err != nil {
+		return nil, err
+	} else if version == 0 {
+		return nil, nil
+	}
+
+	for _, m := range migrations {
+		if m.version != version {
+			continue
+		}
+
+		err := s.transact(m.down, sq.Delete(model.SchemaMigrationTableName).Where(sq.Eq{"version": version}))
+		if err != nil {
+			return nil, err
+		}
+
+		return &model.SchemaMigration{Version: m.version, Name: m.name}, nil
+	}
+
+	return nil, errors.Errorf("Migration version %d is unknown.", version)
+}
+
+// transact executes the statements and records the migration in a transaction.
+func (s SchemaMigrationService) transact(stmts []string, record sq.Sqlizer) (err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err == nil {
+			err = tx.Commit()
+		} else if rErr := tx.Rollback(); rErr != nil {
+			err = errors.Wrap(err, rErr.Error())
+		}
+	}()
+
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	query, args, err := record.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(query, args...)
+	return err
+}
@@ -0,0 +1,89 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/178inaba/tweeraser/model/sqlite"
+	"github.com/stretchr/testify/suite"
+)
+
+type schemaMigrationSuite struct {
+	suite.Suite
+
+	db      *testDB
+	service model.SchemaMigrationService
+}
+
+func TestSchemaMigrationSuite(t *testing.T) {
+	suite.Run(t, new(schemaMigrationSuite))
+}
+
+func (s *schemaMigrationSuite) SetupTest() {
+	// Create test db migrated to the latest.
+	db, err := openTestDB()
+	s.Require().NoError(err)
+
+	s.db = db
+	s.service = sqlite.NewSchemaMigrationService(db.DB)
+}
+
+func (s *schemaMigrationSuite) TestStatuses() {
+	sms, err := s.service.Statuses()
+	s.NoError(err)
+	s.Len(sms, int(s.service.LatestVersion()))
+
+	for i, sm := range sms {
+		s.Equal(uint64(i+1), sm.Version)
+		s.NotEmpty(sm.Name)
+		s.True(sm.Applied())
+	}
+}
+
+func (s *schemaMigrationSuite) TestDownUp() {
+	latest := s.service.LatestVersion()
+	version, err := s.service.Version()
+	s.NoError(err)
+	s.Equal(latest, version)
+
+	// Nothing to apply.
+	sms, err := s.service.Up()
+	s.NoError(err)
+	s.Len(sms, 0)
+
+	sm, err := s.service.Down()
+	s.NoError(err)
+	s.Equal(latest, sm.Version)
+
+	version, err = s.service.Version()
+	s.NoError(err)
+	s.Equal(latest-1, version)
+
+	statuses, err := s.service.Statuses()
+	s.NoError(err)
+	s.False(statuses[latest-1].Applied())
+
+	sms, err = s.service.Up()
+	s.NoError(err)
+	s.Len(sms, 1)
+	s.Equal(latest, sms[0].Version)
+
+	version, err = s.service.Version()
+	s.NoError(err)
+	s.Equal(latest, version)
+
+	// Roll back all.
+	for v := latest; v > 0; v-- {
+		sm, err = s.service.Down()
+		s.NoError(err)
+		s.Equal(v, sm.Version)
+	}
+
+	sm, err = s.service.Down()
+	s.NoError(err)
+	s.Nil(sm)
+}
+
+func (s *schemaMigrationSuite) TearDownTest() {
+	s.db.Close()
+}
@@ -0,0 +1,139 @@
+package sqlite
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/178inaba/tweeraser/config"
+	"github.com/178inaba/tweeraser/model"
+	sq "github.com/Masterminds/squirrel"
+
+	// Register pure go sqlite driver.
+	_ "modernc.org/sqlite"
+)
+
+// bulkInsertRowCnt is the number of rows inserted by one statement.
+// It keeps the variables of one statement (10 columns per row) under 999, the limit of old SQLite.
+const bulkInsertRowCnt = 90
+
+type beginner interface {
+	Begin() (*sql.Tx, error)
+}
+
+// Open is open sqlite database from [database] config.
+// DSN takes precedence over Path.
+func Open(conf config.Database) (*sql.DB, error) {
+	if conf.DSN != "" {
+		return sql.Open("sqlite", conf.DSN)
+	}
+
+	return OpenPath(conf.Path)
+}
+
+// OpenPath is open sqlite database file of the path.
+// Foreign keys are enforced and a locked database is waited for up to 5 seconds.
+func OpenPath(path string) (*sql.DB, error) {
+	return sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+}
+
+func isNoSuchTable(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "no such table")
+}
+
+// nullableID returns nil for the id 0 to store NULL into the nullable foreign key.
+func nullableID(id uint64) interface{} {
+	if id == 0 {
+		return nil
+	}
+
+	return id
+}
+
+// queryKeyCounts runs the query that selects the key and the count of the group.
+func queryKeyCounts(pr prepareRunner, query string, args []interface{}) ([]*model.KeyCount, error) {
+	rows, err := pr.Query(query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var kcs []*model.KeyCount
+	for rows.Next() {
+		var kc model.KeyCount
+		err := rows.Scan(&kc.Key, &kc.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		kcs = append(kcs, &kc)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return kcs, nil
+}
+
+type prepareRunner struct {
+	preparer sq.Preparer
+	canClose bool
+}
+
+func newPrepareRunner(preparer sq.Preparer) prepareRunner {
+	canClose := false
+	if _, ok := preparer.(*sql.DB); ok {
+		canClose = true
+	}
+
+	return prepareRunner{preparer: preparer, canClose: canClose}
+}
+
+func (r prepareRunner) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := r.preparer.Prepare(query)
+	if err != nil {
+		return nil, err
+	} else if r.canClose {
+		defer stmt.Close()
+	}
+
+	return stmt.Query(args...)
+}
+
+func (r prepareRunner) Exec(query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := r.preparer.Prepare(query)
+	if err != nil {
+		return nil, err
+	} else if r.canClose {
+		defer stmt.Close()
+	}
+
+	return stmt.Exec(args...)
+}
+
+func (r prepareRunner) QueryRow(query string, args ...interface{}) sq.RowScanner {
+	stmt, err := r.preparer.Prepare(query)
+	if err != nil {
+		return &row{err: err}
+	} else if r.canClose {
+		defer stmt.Close()
+	}
+
+	return &row{RowScanner: stmt.QueryRow(args...)}
+}
+
+type row struct {
+	sq.RowScanner
+	err error
+}
+
+func (r *row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	return r.RowScanner.Scan(dest...)
+}
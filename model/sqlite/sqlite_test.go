@@ -0,0 +1,42 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/178inaba/tweeraser/model/sqlite"
+)
+
+// testDB is a migrated sqlite database in a temporary directory.
+type testDB struct {
+	*sql.DB
+	dir string
+}
+
+func openTestDB() (*testDB, error) {
+	dir, err := ioutil.TempDir("", "tweeraser_test")
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sqlite.OpenPath(filepath.Join(dir, "tweeraser_test.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	if _, err := sqlite.NewSchemaMigrationService(db).Up(); err != nil {
+		db.Close()
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return &testDB{DB: db, dir: dir}, nil
+}
+
+func (db *testDB) Close() error {
+	defer os.RemoveAll(db.dir)
+	return db.DB.Close()
+}
@@ -51,6 +51,8 @@ func (t *TwitterUser) CheckWantUpdate(tu *TwitterUser) bool {
 }
 
 // TwitterUserService is twitter user service interface.
+// TwitterUsers returns the stored users in order of the user id.
 type TwitterUserService interface {
 	InsertUpdate(tu *TwitterUser) error
+	TwitterUsers() ([]*TwitterUser, error)
 }
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// WipeJobTableName is wipe job table name.
+const WipeJobTableName = "wipe_jobs"
+
+// WipeJob is account wipe job object.
+// CompletedStages is comma separated stage names.
+type WipeJob struct {
+	ID              uint64
+	TwitterUserID   uint64
+	CompletedStages string
+	Finished        bool
+	UpdatedAt       time.Time
+	CreatedAt       time.Time
+}
+
+// IsCompleted checks if the stage is already completed.
+func (j *WipeJob) IsCompleted(stage string) bool {
+	for _, s := range strings.Split(j.CompletedStages, ",") {
+		if s == stage {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Complete adds the stage to completed stages.
+func (j *WipeJob) Complete(stage string) {
+	if j.IsCompleted(stage) {
+		return
+	} else if j.CompletedStages == "" {
+		j.CompletedStages = stage
+		return
+	}
+
+	j.CompletedStages += "," + stage
+}
+
+// WipeJobService is wipe job service interface.
+type WipeJobService interface {
+	LatestUnfinished(userID uint64) (*WipeJob, error)
+	Insert(wj *WipeJob) (uint64, error)
+	Update(wj *WipeJob) error
+}
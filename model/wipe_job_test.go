@@ -0,0 +1,23 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWipeJobComplete(t *testing.T) {
+	wj := &model.WipeJob{}
+	assert.False(t, wj.IsCompleted("tweets"))
+
+	wj.Complete("tweets")
+	assert.True(t, wj.IsCompleted("tweets"))
+	assert.Equal(t, "tweets", wj.CompletedStages)
+
+	wj.Complete("likes")
+	wj.Complete("tweets")
+	assert.True(t, wj.IsCompleted("likes"))
+	assert.False(t, wj.IsCompleted("dms"))
+	assert.Equal(t, "tweets,likes", wj.CompletedStages)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/178inaba/tweeraser/model"
+	log "github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+)
+
+// purgeContent scrubs the content of the stored erase tweets by --store-content none or hash.
+// With mysql the text of the erased likes, the erased direct messages and the archived tweets already erased
+// is emptied also, because these tables have no column for the hash.
+// The backed up media files of the scrubbed tweets are removed after the scrub.
+func (c tweetEraseClient) purgeContent() error {
+	if *storeContent == model.StoreContentFull {
+		return errors.New("Specify --store-content none or hash.")
+	}
+
+	ets, err := c.eraseTweetService.EraseTweets(c.user.UserID)
+	if err != nil {
+		return err
+	}
+
+	var scrubbed []*model.EraseTweet
+	var mediaPaths []string
+	for _, et := range ets {
+		stored := *et
+		et.StoreContent(*storeContent, c.config.ContentHashSalt)
+		if *et != stored {
+			scrubbed = append(scrubbed, et)
+		}
+
+		if stored.MediaPaths != "" {
+			mediaPaths = append(mediaPaths, strings.Split(stored.MediaPaths, ",")...)
+		}
+	}
+
+	if len(scrubbed) > 0 {
+		if err := c.eraseTweetService.ScrubContent(scrubbed); err != nil {
+			return err
+		}
+	}
+
+	// The media file is shared by the tweets of the same media, so the removed file may not exist.
+	var removed int
+	for _, p := range mediaPaths {
+		if err := os.Remove(p); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return errors.Wrapf(err, "remove media %s", p)
+		}
+
+		removed++
+	}
+
+	fields := log.Fields{"store_content": *storeContent, "count": len(scrubbed), "media": removed}
+	for _, ts := range c.tableScrubs() {
+		cnt, err := ts.scrub(c.user.UserID)
+		if err != nil {
+			return err
+		}
+
+		fields[ts.table] = cnt
+	}
+
+	log.WithFields(fields).Info("Successfully purged content!")
+	return nil
+}
+
+// tableScrub empties the text of the table of the user and returns the number of the scrubbed rows.
+type tableScrub struct {
+	table string
+	scrub func(userID uint64) (uint64, error)
+}
+
+// tableScrubs returns the scrubs of the tables stored only in mysql.
+func (c tweetEraseClient) tableScrubs() []tableScrub {
+	var tss []tableScrub
+	if c.archivedTweetService != nil {
+		tss = append(tss, tableScrub{table: model.ArchivedTweetTableName, scrub: c.archivedTweetService.ScrubErasedContent})
+	}
+
+	if c.eraseLikeService != nil {
+		tss = append(tss, tableScrub{table: model.EraseLikeTableName, scrub: c.eraseLikeService.ScrubContent})
+	}
+
+	if c.eraseDirectMessageService != nil {
+		tss = append(tss, tableScrub{table: model.EraseDirectMessageTableName, scrub: c.eraseDirectMessageService.ScrubContent})
+	}
+
+	return tss
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/178inaba/tweeraser/config"
+	"github.com/ChimeraCoder/anaconda"
+	log "github.com/Sirupsen/logrus"
+	"github.com/garyburd/go-oauth/oauth"
+)
+
+const (
+	twitterAPIBaseURL = "https://api.twitter.com/1.1/"
+
+	// rateLimitWindow is the rate limit window of twitter api.
+	rateLimitWindow = 15 * time.Minute
+
+	// rateLimitMaxRetries is the max number of the retries of the request exceeding the rate limit.
+	rateLimitMaxRetries = 3
+)
+
+// rawAPI calls twitter api endpoints that anaconda does not support.
+type rawAPI struct {
+	client      *oauth.Client
+	credentials *oauth.Credentials
+}
+
+func newRawAPI(conf *config.Config) rawAPI {
+	return rawAPI{
+		client: &oauth.Client{Credentials: oauth.Credentials{
+			Token: conf.ConsumerKey, Secret: conf.ConsumerSecret}},
+		credentials: &oauth.Credentials{Token: conf.AccessToken, Secret: conf.AccessTokenSecret},
+	}
+}
+
+func (a rawAPI) get(path string, v url.Values, data interface{}) error {
+	return a.do(path, func() (*http.Response, error) {
+		return a.client.Get(http.DefaultClient, a.credentials, twitterAPIBaseURL+path, v)
+	}, data)
+}
+
+func (a rawAPI) post(path string, v url.Values, data interface{}) error {
+	return a.do(path, func() (*http.Response, error) {
+		return a.client.Post(http.DefaultClient, a.credentials, twitterAPIBaseURL+path, v)
+	}, data)
+}
+
+func (a rawAPI) delete(path string, v url.Values, data interface{}) error {
+	return a.do(path, func() (*http.Response, error) {
+		return a.client.Delete(http.DefaultClient, a.credentials, twitterAPIBaseURL+path, v)
+	}, data)
+}
+
+// do sends the request and decodes the response.
+// When the rate limit is exceeded (429), it waits until x-rate-limit-reset and sends the request again.
+func (a rawAPI) do(path string, send func() (*http.Response, error), data interface{}) error {
+	for retry := 0; ; retry++ {
+		resp, err := send()
+		if err != nil {
+			return err
+		}
+
+		err = decodeResponse(resp, data)
+		apiErr, ok := err.(*anaconda.ApiError)
+		if !ok || apiErr.StatusCode != http.StatusTooManyRequests || retry == rateLimitMaxRetries {
+			return err
+		}
+
+		// Without x-rate-limit-reset, the next window of 15 minutes is waited.
+		reset := time.Now().Add(rateLimitWindow)
+		if isRateLimitError, nextWindow := apiErr.RateLimitCheck(); isRateLimitError {
+			reset = nextWindow
+		}
+
+		wait := reset.Sub(time.Now()) + time.Second
+		if wait < time.Second {
+			wait = time.Second
+		}
+
+		log.WithFields(log.Fields{"path": path, "reset": reset.UTC().Format("2006-01-02 15:04:05")}).
+			Warnf("Rate limit exceeded, wait %s.", wait)
+		time.Sleep(wait)
+	}
+}
+
+// decodeResponse decodes the response json into data.
+// Error status is returned as *anaconda.ApiError like anaconda api.
+func decodeResponse(resp *http.Response, data interface{}) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+
+		apiErr := &anaconda.ApiError{StatusCode: resp.StatusCode,
+			Header: resp.Header, Body: string(b), URL: resp.Request.URL}
+		json.Unmarshal(b, &apiErr.Decoded)
+		return apiErr
+	}
+
+	if data == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(data)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/178inaba/tweeraser/model"
+	log "github.com/Sirupsen/logrus"
+)
+
+const (
+	renderMediaDirName = "media"
+	renderTimeLayout   = "2006-01-02 15:04:05 MST"
+)
+
+const renderStyle = `<style>
+body { font-family: sans-serif; max-width: 640px; margin: 0 auto; padding: 16px; }
+article { border-bottom: 1px solid #ddd; padding: 12px 0; }
+.text { white-space: pre-wrap; word-wrap: break-word; }
+.meta { color: #777; font-size: small; }
+img, video { max-width: 100%; }
+</style>`
+
+var renderIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Erased tweets of @{{.ScreenName}}</title>
+` + renderStyle + `
+<script src="search.js"></script>
+</head>
+<body>
+<h1>Erased tweets of @{{.ScreenName}}</h1>
+<input id="query" type="search" placeholder="Search" autofocus>
+<ul id="results"></ul>
+<ul id="months">
+{{range .Months}}<li><a href="{{.Name}}.html">{{.Name}}</a> ({{len .Tweets}})</li>
+{{end}}</ul>
+<script>
+document.getElementById("query").addEventListener("input", function (e) {
+  var q = e.target.value.toLowerCase();
+  var results = document.getElementById("results");
+  results.innerHTML = "";
+  document.getElementById("months").style.display = q ? "none" : "";
+  if (!q) {
+    return;
+  }
+
+  tweets.forEach(function (t) {
+    if (t.text.toLowerCase().indexOf(q) < 0) {
+      return;
+    }
+
+    var a = document.createElement("a");
+    a.href = t.month + ".html#" + t.id;
+    a.textContent = t.posted_at + " " + t.text;
+    var li = document.createElement("li");
+    li.appendChild(a);
+    results.appendChild(li);
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+var renderMonthTemplate = template.Must(template.New("month").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}} - Erased tweets of @{{.ScreenName}}</title>
+` + renderStyle + `
+</head>
+<body>
+<p><a href="index.html">Index</a></p>
+<h1>{{.Name}}</h1>
+{{range .Tweets}}<article id="{{.ID}}">
+<div class="text">{{.Text}}</div>
+{{range .Media}}{{if .IsVideo}}<video src="{{.Path}}" controls></video>{{else}}<img src="{{.Path}}" alt="">{{end}}
+{{end}}<div class="meta">Posted at {{.PostedAt}}{{if .Source}} via {{.Source}}{{end}} / Erased at {{.ErasedAt}}</div>
+</article>
+{{end}}</body>
+</html>
+`))
+
+// renderMonth is the page of the erased tweets posted in the month.
+type renderMonth struct {
+	ScreenName string
+	Name       string
+	Tweets     []renderTweet
+}
+
+type renderTweet struct {
+	ID       uint64
+	Text     string
+	Source   string
+	PostedAt string
+	ErasedAt string
+	Media    []renderMedia
+}
+
+type renderMedia struct {
+	Path    string
+	IsVideo bool
+}
+
+// searchTweet is the tweet of the search index written to search.js.
+type searchTweet struct {
+	ID       string `json:"id"`
+	Month    string `json:"month"`
+	Text     string `json:"text"`
+	PostedAt string `json:"posted_at"`
+}
+
+// render generates the static html site of the erased tweets into --out directory.
+// The site has the index page with search and the page per posted month.
+// The backed up media files are copied into the site, so it can be browsed without the database.
+func (c tweetEraseClient) render() error {
+	ets, err := c.eraseTweetService.EraseTweets(c.user.UserID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Join(*renderOut, renderMediaDirName), 0755); err != nil {
+		return err
+	}
+
+	var months []*renderMonth
+	var searchTweets []searchTweet
+	for _, et := range ets {
+		name := et.PostedAt.UTC().Format("2006-01")
+		if len(months) == 0 || months[len(months)-1].Name != name {
+			months = append(months, &renderMonth{ScreenName: c.user.ScreenName, Name: name})
+		}
+
+		media, err := copyRenderMedia(et)
+		if err != nil {
+			return err
+		}
+
+		rt := renderTweet{ID: et.TwitterTweetID, Text: et.Tweet, Source: sourceText(rawTweet(et).Source), Media: media,
+			PostedAt: et.PostedAt.UTC().Format(renderTimeLayout), ErasedAt: et.CreatedAt.UTC().Format(renderTimeLayout)}
+		m := months[len(months)-1]
+		m.Tweets = append(m.Tweets, rt)
+		searchTweets = append(searchTweets, searchTweet{ID: strconv.FormatUint(rt.ID, 10), Month: name,
+			Text: rt.Text, PostedAt: rt.PostedAt})
+	}
+
+	for _, m := range months {
+		if err := renderFile(filepath.Join(*renderOut, m.Name+".html"), renderMonthTemplate, m); err != nil {
+			return err
+		}
+	}
+
+	if err := writeSearchJS(filepath.Join(*renderOut, "search.js"), searchTweets); err != nil {
+		return err
+	}
+
+	index := struct {
+		ScreenName string
+		Months     []*renderMonth
+	}{ScreenName: c.user.ScreenName, Months: months}
+	if err := renderFile(filepath.Join(*renderOut, "index.html"), renderIndexTemplate, index); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"out": *renderOut, "months": len(months), "count": len(ets)}).Info("Successfully rendered!")
+	return nil
+}
+
+// copyRenderMedia copies the backed up media of the tweet into the media directory of the site.
+// The media not found (e.g. erased without --backup-media or removed after) is skipped.
+func copyRenderMedia(et *model.EraseTweet) ([]renderMedia, error) {
+	if et.MediaPaths == "" {
+		return nil, nil
+	}
+
+	var media []renderMedia
+	for _, p := range strings.Split(et.MediaPaths, ",") {
+		src, err := os.Open(p)
+		if os.IsNotExist(err) {
+			log.WithFields(log.Fields{"tweet_id": et.TwitterTweetID, "path": p}).Warn("Backed up media is not found.")
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		// The media store names the file by the hash of the content, so the name is unique.
+		name := filepath.Base(p)
+		err = copyFile(filepath.Join(*renderOut, renderMediaDirName, name), src)
+		src.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		media = append(media, renderMedia{Path: renderMediaDirName + "/" + name,
+			IsVideo: strings.EqualFold(filepath.Ext(name), ".mp4")})
+	}
+
+	return media, nil
+}
+
+func copyFile(path string, src io.Reader) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func renderFile(path string, t *template.Template, data interface{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := t.Execute(f, data); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// writeSearchJS writes the search index as a script, so the index page can search it without a server.
+func writeSearchJS(path string, searchTweets []searchTweet) error {
+	if searchTweets == nil {
+		searchTweets = []searchTweet{}
+	}
+
+	b, err := json.Marshal(searchTweets)
+	if err != nil {
+		return err
+	}
+
+	return copyFile(path, strings.NewReader("var tweets = "+string(b)+";\n"))
+}
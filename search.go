@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+	log "github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+)
+
+const (
+	searchDateLayout = "2006-01-02"
+
+	// fullArchiveDateLayout is the layout of fromDate and toDate of the full-archive search.
+	fullArchiveDateLayout = "200601021504"
+)
+
+// fullArchiveResult is the page of the premium full-archive search.
+type fullArchiveResult struct {
+	Results []anaconda.Tweet `json:"results"`
+	Next    string           `json:"next"`
+}
+
+// searchIDs searches the user tweets with since/until windows from the since date to today.
+// The standard search api returns only the tweets of about the last 7 days, which the timeline covers,
+// so the windows are searched by the premium full-archive search of search_env of the config.
+func (c tweetEraseClient) searchIDs() ([]uint64, error) {
+	if c.config.SearchEnv == "" {
+		return nil, errors.New("Search requires search_env of the premium full-archive search in the config.")
+	} else if *searchWindow < 1 {
+		return nil, errors.Errorf("Search window days must be positive: %d.", *searchWindow)
+	}
+
+	since, err := c.searchSinceDate()
+	if err != nil {
+		return nil, err
+	}
+
+	idsMap := map[uint64]struct{}{}
+	now := time.Now().UTC()
+	for since.Before(now) {
+		until := since.AddDate(0, 0, *searchWindow)
+		if until.After(now) {
+			until = now
+		}
+
+		windowIDs, err := c.searchWindowIDs(since, until)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range windowIDs {
+			idsMap[id] = struct{}{}
+		}
+
+		log.WithFields(log.Fields{"since": since.Format(searchDateLayout),
+			"until": until.Format(searchDateLayout), "count": len(windowIDs)}).Info("Searched tweets.")
+		since = until
+	}
+
+	ids := make([]uint64, 0, len(idsMap))
+	for id := range idsMap {
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (c tweetEraseClient) searchSinceDate() (time.Time, error) {
+	if *searchSince != "" {
+		return time.Parse(searchDateLayout, *searchSince)
+	}
+
+	v := url.Values{}
+	v.Set("include_entities", "false")
+	v.Set("skip_status", "true")
+	u, err := c.api.GetSelf(v)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	createdAt, err := time.Parse(time.RubyDate, u.CreatedAt)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return createdAt.UTC().Truncate(24 * time.Hour), nil
+}
+
+// searchWindowIDs searches the user tweets posted from since until until by the full-archive search.
+func (c tweetEraseClient) searchWindowIDs(since, until time.Time) ([]uint64, error) {
+	v := url.Values{}
+	v.Set("query", fmt.Sprintf("from:%s", c.user.ScreenName))
+	v.Set("fromDate", since.Format(fullArchiveDateLayout))
+	v.Set("toDate", until.Format(fullArchiveDateLayout))
+	v.Set("maxResults", fmt.Sprint(100))
+
+	api := newRawAPI(c.config)
+	path := fmt.Sprintf("tweets/search/fullarchive/%s.json", c.config.SearchEnv)
+	var ids []uint64
+	for {
+		var r fullArchiveResult
+		if err := api.get(path, v, &r); err != nil {
+			return nil, err
+		}
+
+		for _, t := range r.Results {
+			ids = append(ids, uint64(t.Id))
+		}
+
+		if r.Next == "" {
+			return ids, nil
+		}
+
+		v.Set("next", r.Next)
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/178inaba/tweeraser/model"
+)
+
+// Stats output formats.
+const (
+	statsFormatTable = "table"
+	statsFormatCSV   = "csv"
+	statsFormatJSON  = "json"
+)
+
+// htmlTagRegexp matches the tags of the source html (e.g. <a href="..." rel="nofollow">Twitter Web Client</a>).
+var htmlTagRegexp = regexp.MustCompile(`<[^>]*>`)
+
+// statsCount is the count of the key in a section of the stats.
+type statsCount struct {
+	Key   string `json:"key"`
+	Count uint64 `json:"count"`
+}
+
+// erasedStats is the stats of the erase history of the user.
+type erasedStats struct {
+	ErasedByMonth  []statsCount `json:"erased_by_month"`
+	ErasedBySource []statsCount `json:"erased_by_source"`
+	ErrorsByKind   []statsCount `json:"errors_by_kind"`
+	Remaining      []statsCount `json:"remaining"`
+}
+
+// sections returns the counts with the section names in the output order.
+func (s erasedStats) sections() ([]string, [][]statsCount) {
+	return []string{"erased_by_month", "erased_by_source", "errors_by_kind", "remaining"},
+		[][]statsCount{s.ErasedByMonth, s.ErasedBySource, s.ErrorsByKind, s.Remaining}
+}
+
+// stats reports the erased tweets by month, source and error kind, and the remaining candidates.
+func (c tweetEraseClient) stats() error {
+	s, err := c.erasedStats()
+	if err != nil {
+		return err
+	}
+
+	switch *statsFormat {
+	case statsFormatCSV:
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"section", "key", "count"})
+		names, sections := s.sections()
+		for i, section := range sections {
+			for _, sc := range section {
+				w.Write([]string{names[i], sc.Key, strconv.FormatUint(sc.Count, 10)})
+			}
+		}
+
+		w.Flush()
+		return w.Error()
+	case statsFormatJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "SECTION\tKEY\tCOUNT")
+	names, sections := s.sections()
+	for i, section := range sections {
+		for _, sc := range section {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", names[i], sc.Key, sc.Count)
+		}
+	}
+
+	return w.Flush()
+}
+
+func (c tweetEraseClient) erasedStats() (*erasedStats, error) {
+	userID := c.user.UserID
+	byMonth, err := c.eraseTweetService.CountByPostedMonth(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	bySource, err := c.eraseTweetService.CountBySource(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byStatusCode, err := c.countErrorsByStatusCode()
+	if err != nil {
+		return nil, err
+	}
+
+	remaining, err := c.remainingCounts()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &erasedStats{ErasedByMonth: statsCounts(byMonth, nil), Remaining: remaining,
+		ErasedBySource: statsCounts(bySource, sourceName), ErrorsByKind: statsCounts(byStatusCode, errorKind)}
+	return s, nil
+}
+
+// countErrorsByStatusCode counts the errors by the status code.
+// The errors of the tweets erased by retry are excluded as remainingCounts.
+func (c tweetEraseClient) countErrorsByStatusCode() ([]*model.KeyCount, error) {
+	statusCodes, err := c.eraseErrorService.TweetStatusCodes(c.user.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint64, 0, len(statusCodes))
+	for id := range statusCodes {
+		ids = append(ids, id)
+	}
+
+	ids, err = c.excludeIDs(ids, c.eraseTweetService.AlreadyEraseTweetIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[int]uint64{}
+	for _, id := range ids {
+		counts[int(statusCodes[id])]++
+	}
+
+	codes := make([]int, 0, len(counts))
+	for code := range counts {
+		codes = append(codes, code)
+	}
+
+	sort.Ints(codes)
+	kcs := make([]*model.KeyCount, len(codes))
+	for i, code := range codes {
+		kcs[i] = &model.KeyCount{Key: strconv.Itoa(code), Count: counts[code]}
+	}
+
+	return kcs, nil
+}
+
+// remainingCounts counts the tweets which are not erased yet.
+// The failed tweets except not found can be erased by retry.
+// The archived tweets are counted only with mysql, the archive is not stored in the other databases.
+func (c tweetEraseClient) remainingCounts() ([]statsCount, error) {
+	retryIDs, err := c.eraseErrorService.RetryTweetIDs(c.user.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	retryIDs, err = c.excludeIDs(retryIDs, c.eraseTweetService.AlreadyEraseTweetIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := []statsCount{{Key: "retryable_errors", Count: uint64(len(retryIDs))}}
+	if c.archivedTweetService == nil {
+		return counts, nil
+	}
+
+	archivedIDs, err := c.archivedTweetService.TweetIDs(c.user.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	archivedIDs, err = c.excludeIDs(archivedIDs,
+		c.eraseTweetService.AlreadyEraseTweetIDs, c.eraseErrorService.TweetNotFoundIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(counts, statsCount{Key: "archived_tweets", Count: uint64(len(archivedIDs))}), nil
+}
+
+// statsCounts converts the key counts with the key label. Counts of the same label are merged.
+func statsCounts(kcs []*model.KeyCount, label func(key string) string) []statsCount {
+	var scs []statsCount
+	indexes := map[string]int{}
+	for _, kc := range kcs {
+		key := kc.Key
+		if label != nil {
+			key = label(key)
+		}
+
+		if i, ok := indexes[key]; ok {
+			scs[i].Count += kc.Count
+			continue
+		}
+
+		indexes[key] = len(scs)
+		scs = append(scs, statsCount{Key: key, Count: kc.Count})
+	}
+
+	return scs
+}
+
+// sourceText returns the text of the source html.
+func sourceText(source string) string {
+	return html.UnescapeString(htmlTagRegexp.ReplaceAllString(source, ""))
+}
+
+// sourceName returns the client name of the source html.
+func sourceName(source string) string {
+	name := sourceText(source)
+	if name == "" {
+		return "unknown"
+	}
+
+	return name
+}
+
+// errorKind returns the label of the status code. 0 is the error before the api response.
+func errorKind(statusCode string) string {
+	code, err := strconv.Atoi(statusCode)
+	if err != nil {
+		return statusCode
+	} else if code == 0 {
+		return "non-api error"
+	}
+
+	return fmt.Sprintf("%d %s", code, http.StatusText(code))
+}
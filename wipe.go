@@ -0,0 +1,212 @@
+package main
+
+import (
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/178inaba/tweeraser/model"
+	"github.com/ChimeraCoder/anaconda"
+	log "github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+)
+
+// wipeStageNames is the stage names of wipe in running order.
+var wipeStageNames = []string{
+	"tweets", "retweets", "likes", "dms", "followings", "lists", "saved_searches"}
+
+type wipeStage struct {
+	name string
+	run  func() (uint64, error)
+}
+
+func (c tweetEraseClient) wipeStages() []wipeStage {
+	runs := map[string]func() error{
+		"tweets":         c.eraseOwnTweets,
+		"retweets":       c.eraseRetweets,
+		"likes":          c.eraseLikes,
+		"dms":            c.eraseDirectMessages,
+		"followings":     c.followings,
+		"lists":          c.eraseLists,
+		"saved_searches": c.eraseSavedSearches,
+	}
+
+	stages := make([]wipeStage, len(wipeStageNames))
+	for i, name := range wipeStageNames {
+		stages[i] = wipeStage{name: name, run: c.countStageFailures(runs[name])}
+	}
+
+	return stages
+}
+
+// countStageFailures returns the run of the stage returning the number of the failed erases in the stage.
+func (c tweetEraseClient) countStageFailures(run func() error) func() (uint64, error) {
+	return func() (uint64, error) {
+		before := c.failureCount()
+		err := run()
+		return c.failureCount() - before, err
+	}
+}
+
+func (c tweetEraseClient) wipe() error {
+	if c.wipeJobService == nil {
+		return errors.New("Wipe requires mysql database.")
+	}
+
+	skips := map[string]struct{}{}
+	for _, name := range *wipeSkipStages {
+		skips[name] = struct{}{}
+	}
+
+	wj, err := c.wipeJobService.LatestUnfinished(c.user.UserID)
+	if err != nil {
+		return err
+	}
+
+	if wj == nil || *wipeRestart {
+		wj = &model.WipeJob{TwitterUserID: c.user.UserID}
+		wj.ID, err = c.wipeJobService.Insert(wj)
+		if err != nil {
+			return err
+		}
+	} else {
+		log.WithFields(log.Fields{"job_id": wj.ID,
+			"completed_stages": wj.CompletedStages}).Info("Resume wipe job.")
+	}
+
+	var incomplete bool
+	for _, stage := range c.wipeStages() {
+		l := log.WithFields(log.Fields{"job_id": wj.ID, "stage": stage.name})
+		if _, ok := skips[stage.name]; ok {
+			l.Info("Skip stage.")
+			continue
+		} else if wj.IsCompleted(stage.name) {
+			l.Info("Stage is already completed.")
+			continue
+		}
+
+		l.Info("Start stage.")
+		failures, err := stage.run()
+		if err != nil {
+			return errors.Wrapf(err, "wipe stage %s", stage.name)
+		} else if failures > 0 {
+			// The stage is retried by resuming the job, so the job is not finished.
+			l.WithField("failures", failures).Warn("Stage has failures. Resume the job to retry it.")
+			incomplete = true
+			continue
+		}
+
+		wj.Complete(stage.name)
+		if err := c.wipeJobService.Update(wj); err != nil {
+			return err
+		}
+
+		l.Info("Stage completed.")
+	}
+
+	if incomplete {
+		return errors.Errorf("Wipe job %d has stages with failures.", wj.ID)
+	}
+
+	wj.Finished = true
+	if err := c.wipeJobService.Update(wj); err != nil {
+		return err
+	}
+
+	return c.reportRemaining()
+}
+
+func (c tweetEraseClient) eraseOwnTweets() error {
+	return c.eraseFilteredTweets(false)
+}
+
+func (c tweetEraseClient) eraseRetweets() error {
+	return c.eraseFilteredTweets(true)
+}
+
+// eraseFilteredTweets erases only retweets or only the user's own tweets
+// of the archive (--csv-file or --zip-file) and the timeline.
+func (c tweetEraseClient) eraseFilteredTweets(retweets bool) error {
+	sources := []func() ([]uint64, error){func() ([]uint64, error) {
+		return c.filterTimelineIDs(func(t anaconda.Tweet) bool {
+			return (t.RetweetedStatus != nil) == retweets
+		})
+	}}
+	sourceNames := []string{"timeline"}
+	if *csvFilePath != "" || *zipFilePath != "" {
+		sources = append(sources, func() ([]uint64, error) {
+			return c.archiveTweetIDs(retweets)
+		})
+
+		if *csvFilePath != "" {
+			sourceNames = append(sourceNames, "csv")
+		} else {
+			sourceNames = append(sourceNames, "zip")
+		}
+	}
+
+	ids, err := collectIDs(sources...)
+	if err != nil {
+		return err
+	}
+
+	return c.checkBeforeEraseIDs(ids, sourceNames, map[string]interface{}{"retweets": retweets})
+}
+
+func (c tweetEraseClient) archiveTweetIDs(retweets bool) ([]uint64, error) {
+	var rc io.ReadCloser
+	var err error
+	if *csvFilePath != "" {
+		rc, err = os.Open(*csvFilePath)
+	} else {
+		rc, err = openZipFile(*zipFilePath, tweetsCsvFileName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	ats, err := readArchiveCsv(rc, c.user.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uint64
+	for _, at := range ats {
+		if (at.RetweetedStatusID != 0) == retweets {
+			ids = append(ids, at.TwitterTweetID)
+		}
+	}
+
+	return ids, nil
+}
+
+func (c tweetEraseClient) reportRemaining() error {
+	v := url.Values{}
+	v.Set("include_entities", "false")
+	v.Set("skip_status", "true")
+	u, err := c.api.GetSelf(v)
+	if err != nil {
+		return err
+	}
+
+	dms, err := c.eventDirectMessages()
+	if err != nil {
+		return err
+	}
+
+	lists, err := c.ownedLists()
+	if err != nil {
+		return err
+	}
+
+	searches, err := c.savedSearches()
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"tweets": u.StatusesCount, "likes": u.FavouritesCount,
+		"recent_dms": len(dms), "followings": u.FriendsCount, "lists": len(lists),
+		"saved_searches": len(searches)}).Info("Remaining after wipe.")
+	return nil
+}